@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// batch prints the results of several calculations in the selected output
+// format: blocks separated by blank lines, one JSON array, or CSV rows under
+// a single header. Failed calculations are reported without stopping the
+// batch, and finish exits with status 1 if any failed. With -sort the
+// networks are held back until finish and printed in address order.
+type batch struct {
+	results   []any
+	csvWriter *csv.Writer
+	sorted    []ipcalc.Info
+	failed    bool
+	printed   bool
+}
+
+func newBatch() *batch {
+	b := &batch{}
+	if *csvOutput {
+		b.csvWriter = newCSVWriter(os.Stdout)
+	}
+	return b
+}
+
+func (b *batch) add(info ipcalc.Info, err error) {
+	if err != nil {
+		b.failed = true
+	}
+	if *sortOutput && err == nil {
+		b.sorted = append(b.sorted, info)
+		return
+	}
+	b.print(info, err)
+}
+
+func (b *batch) print(info ipcalc.Info, err error) {
+	switch {
+	case *jsonOutput && err != nil:
+		b.results = append(b.results, jsonError{Error: err.Error()})
+	case *jsonOutput:
+		b.results = append(b.results, newJSONOutput(info))
+	case err != nil:
+		fmt.Fprintln(os.Stderr, err)
+	case *csvOutput:
+		b.csvWriter.Write(csvRecord(info))
+	default:
+		if b.printed && !compactOutput() {
+			fmt.Println()
+		}
+		printInfo(info)
+		b.printed = true
+	}
+}
+
+// addResult adds the result of an expression, which is printed as a single
+// line or JSON string.
+func (b *batch) addResult(result string, err error) {
+	switch {
+	case err != nil:
+		b.failed = true
+		if *jsonOutput {
+			b.results = append(b.results, jsonError{Error: err.Error()})
+		} else {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	case *jsonOutput:
+		b.results = append(b.results, result)
+	default:
+		fmt.Println(result)
+	}
+}
+
+func (b *batch) finish() {
+	sort.SliceStable(b.sorted, func(i, j int) bool {
+		return ipcalc.LessNetwork(b.sorted[i].IPNet(), b.sorted[j].IPNet())
+	})
+	for _, info := range b.sorted {
+		b.print(info, nil)
+	}
+
+	if *jsonOutput {
+		printJSON(b.results)
+	}
+	if b.csvWriter != nil {
+		b.csvWriter.Flush()
+	}
+	if b.failed {
+		os.Exit(1)
+	}
+}
+
+// addLine calculates or evaluates a line of batch input.
+func (b *batch) addLine(line int, text string) {
+	switch {
+	case isExpr(text) && *sortOutput:
+		// Expression results are not networks, so they have no place in
+		// the address order.
+		b.addResult("", fmt.Errorf("line %d: -sort cannot order the result of expression %q", line, text))
+	case isExpr(text) && *csvOutput:
+		// Nor do they have the columns of a CSV row.
+		b.addResult("", fmt.Errorf("line %d: -csv cannot print the result of expression %q", line, text))
+	case isExpr(text):
+		result, err := evalExpr(text)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+		}
+		b.addResult(result, err)
+	default:
+		info, err := calculate(text)
+		if err != nil {
+			err = fmt.Errorf("line %d: %w", line, err)
+		}
+		b.add(info, err)
+	}
+}
+
+// isComment reports whether a trimmed input line is blank or a comment.
+func isComment(line string) bool {
+	return line == "" || strings.HasPrefix(line, "#")
+}
+
+// stdinIsPipe reports whether stdin is redirected from a file or a pipe
+// rather than attached to a terminal.
+func stdinIsPipe() bool {
+	stat, err := os.Stdin.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice == 0
+}
+
+// calculateBatch calculates every line of r as a separate network, or
+// evaluates it if it is an expression such as "10.0.0.0/24 + 1". Blank lines
+// and comments starting with # are skipped. Lines that fail to parse, and
+// expressions when -sort or -csv is given, are reported with their line
+// number.
+// Input without a single line is a usage error.
+func calculateBatch(r io.Reader) {
+	b := newBatch()
+
+	scanner := bufio.NewScanner(r)
+	line := 1
+	for ; scanner.Scan(); line++ {
+		if text := strings.TrimSpace(scanner.Text()); !isComment(text) {
+			b.addLine(line, text)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fail(err)
+		return
+	}
+	if line == 1 {
+		// Nothing was read, as with "ipcalc </dev/null", which is as
+		// much a mistake as giving no arguments on a terminal.
+		usageError()
+		return
+	}
+
+	b.finish()
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBatchCSVRejectsExpressions(t *testing.T) {
+	defer func(old bool) { *csvOutput = old }(*csvOutput)
+	*csvOutput = true
+
+	var buf bytes.Buffer
+	b := &batch{csvWriter: newCSVWriter(&buf)}
+	b.addLine(1, "10.0.0.0/24")
+	b.addLine(2, "10.0.0.0/24 + 1")
+	b.csvWriter.Flush()
+
+	want := "address,netmask,prefix,wildcard,network,hostmin,hostmax,usablerange,broadcast,hosts,class\n" +
+		"10.0.0.0,255.255.255.0,24,0.0.0.255,10.0.0.0,10.0.0.1,10.0.0.254,10.0.0.1 - 10.0.0.254,10.0.0.255,254,\"Class A, Private (10.0.0.0/8)\"\n"
+	if got := buf.String(); got != want {
+		t.Errorf("CSV output = %q, want %q", got, want)
+	}
+	if !b.failed {
+		t.Error("batch did not fail on the expression")
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net"
+	"os"
+	"strings"
+)
+
+// ANSI escape sequences used to color the table.
+const (
+	colorReset      = "\x1b[0m"
+	colorNetwork    = "\x1b[32m"
+	colorBroadcast  = "\x1b[31m"
+	colorMask       = "\x1b[36m"
+	colorNetworkBit = "\x1b[34m"
+	colorHostBit    = "\x1b[33m"
+)
+
+// colorEnabled is set by main when stdout is a terminal and color has not
+// been disabled with -no-color or the NO_COLOR environment variable.
+var colorEnabled bool
+
+func useColor() bool {
+	if *noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	stat, err := os.Stdout.Stat()
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
+
+// paint wraps s in color when color output is enabled.
+func paint(s, color string) string {
+	if !colorEnabled || color == "" {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// binary returns the binary form of ip with the first prefix bits painted as
+// network bits and the rest as host bits. With -annotate each group of bits
+// is followed by its value, which is left unpainted.
+func binary(ip net.IP, prefix int) string {
+	s := ipToBinaryString(ip, prefix, binaryGroup)
+	if *annotate {
+		s = ipToAnnotatedBinaryString(ip, prefix)
+	}
+	if !colorEnabled {
+		return s
+	}
+
+	var b strings.Builder
+	bit := 0
+	current := ""
+	annotation := false
+	for _, c := range s {
+		switch {
+		case c == '(':
+			annotation = true
+			b.WriteString(colorReset)
+			current = ""
+		case c == ')':
+			annotation = false
+		}
+		if annotation || c != '0' && c != '1' {
+			b.WriteRune(c)
+			continue
+		}
+
+		color := colorHostBit
+		if bit < prefix {
+			color = colorNetworkBit
+		}
+		if color != current {
+			b.WriteString(color)
+			current = color
+		}
+		b.WriteRune(c)
+		bit++
+	}
+	b.WriteString(colorReset)
+	return b.String()
+}
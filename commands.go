@@ -0,0 +1,1162 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// commands are operations named by the first argument, as in
+// "ipcalc hosts 192.168.1.0/24". They receive the remaining arguments.
+var commands = map[string]func(args []string){
+	"validate":    validateCommand,
+	"aligned":     alignedCommand,
+	"host":        hostCommand,
+	"dhcp":        dhcpCommand,
+	"util":        utilCommand,
+	"hosts":       hostsCommand,
+	"aggregate":   aggregateCommand,
+	"range":       rangeCommand,
+	"frombounds":  fromBoundsCommand,
+	"cover":       coverCommand,
+	"which":       whichCommand,
+	"diff":        diffCommand,
+	"overlaps":    overlapsCommand,
+	"in":          inCommand,
+	"vlsm":        vlsmCommand,
+	"binary":      binaryCommand,
+	"frombinary":  fromBinaryCommand,
+	"mask":        maskCommand,
+	"fit-hosts":   fitHostsCommand,
+	"fit-subnets": fitSubnetsCommand,
+	"deaggregate": deaggregateCommand,
+	"samenet":     sameNetCommand,
+	"6to4":        sixToFourCommand,
+	"mapped":      mappedCommand,
+	"eui64":       eui64Command,
+	"mac":         macCommand,
+}
+
+// networkCommands are operations written after a network, as in
+// "ipcalc 10.0.0.0/8 contains 10.5.3.2". They receive the calculated
+// network and the arguments following the command name.
+var networkCommands = map[string]func(info ipcalc.Info, args []string){
+	"contains": containsCommand,
+	"next":     adjacentCommand(ipcalc.NextNetwork),
+	"prev":     adjacentCommand(ipcalc.PrevNetwork),
+	"count":    countCommand,
+	"supernet": supernetCommand,
+	"locate":   locateCommand,
+}
+
+// containsCommand prints whether the network contains a host address and
+// exits with status 1 when it does not.
+func containsCommand(info ipcalc.Info, args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		fail(fmt.Errorf("invalid IP address %q", args[0]))
+		return
+	}
+
+	contains := info.Contains(ip)
+	writeCheck(os.Stdout, "contains", contains)
+	if !contains {
+		os.Exit(1)
+	}
+}
+
+// adjacentCommand returns a command printing the network that adjacent
+// computes from the given one.
+func adjacentCommand(adjacent func(*net.IPNet) (*net.IPNet, error)) func(ipcalc.Info, []string) {
+	return func(info ipcalc.Info, args []string) {
+		if len(args) != 0 {
+			usageError()
+			return
+		}
+
+		network, err := adjacent(info.IPNet())
+		if err != nil {
+			fail(err)
+			return
+		}
+		printNetwork(network)
+	}
+}
+
+// countCommand prints how many subnets of a given prefix length fit in the
+// network.
+func countCommand(info ipcalc.Info, args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	child, err := parsePrefix(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	count, err := countSubnets(info, child)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printValue(count.String())
+}
+
+// countSubnets returns how many /child subnets the network of info holds.
+// child must be longer than the network's prefix and fit its family.
+func countSubnets(info ipcalc.Info, child int) (*big.Int, error) {
+	if _, size := info.Mask.Size(); child > size {
+		return nil, fmt.Errorf("prefix length /%d is out of range 0-%d", child, size)
+	}
+	if child <= info.Prefix {
+		return nil, fmt.Errorf("cannot count /%d subnets of a /%d: the subnet prefix must be longer than /%d", child, info.Prefix, info.Prefix)
+	}
+	return ipcalc.SubnetCountBig(info.Prefix, child)
+}
+
+// supernetCommand prints the network with a shorter prefix length that
+// contains the network.
+func supernetCommand(info ipcalc.Info, args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	prefix, err := parsePrefix(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	network, err := ipcalc.Supernet(info.IPNet(), prefix)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printNetwork(network)
+}
+
+// locateCommand prints the subnet of a given prefix length that a host
+// falls into.
+func locateCommand(info ipcalc.Info, args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		fail(fmt.Errorf("invalid IP address %q", args[0]))
+		return
+	}
+	prefix, err := parsePrefix(args[1])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	network, err := ipcalc.Locate(info.IPNet(), ip, prefix)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printNetwork(network)
+}
+
+// hostCommand prints a single address as a host route, a /32 or /128, with
+// its reverse DNS name and integer value.
+func hostCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		fail(fmt.Errorf("invalid IP address %q: host takes a single address without a prefix", args[0]))
+		return
+	}
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil && !strings.Contains(args[0], ":") {
+		bits = 8 * net.IPv4len
+	}
+
+	info, err := calculate(fmt.Sprintf("%s/%d", args[0], bits))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	host := info.IPNet()
+	reverse := ipcalc.ReverseDNSZones(host)[0]
+	integer := ipcalc.IPToInt(info.Address)
+	if *jsonOutput {
+		printJSON(struct {
+			Host    string   `json:"host"`
+			Reverse string   `json:"reverse"`
+			Integer *big.Int `json:"integer"`
+		}{host.String(), reverse, integer})
+		return
+	}
+
+	fmt.Printf("%-10s %s\n", "Host:", host)
+	fmt.Printf("%-10s %s\n", "Reverse:", reverse)
+	fmt.Printf("%-10s %s\n", "Integer:", integer)
+}
+
+// dhcpCommand reports whether a DHCP pool of a given size fits in a network
+// and how many usable addresses remain for static assignment. It exits with
+// status 1 when the pool does not fit.
+func dhcpCommand(args []string) {
+	if len(args) != 3 || args[1] != "pool" {
+		usageError()
+		return
+	}
+
+	info, err := calculate(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	pool, ok := new(big.Int).SetString(args[2], 10)
+	if !ok || pool.Sign() <= 0 {
+		fail(fmt.Errorf("invalid pool size %q", args[2]))
+		return
+	}
+
+	remaining := new(big.Int).Sub(info.Hosts, pool)
+	fits := remaining.Sign() >= 0
+	if *jsonOutput {
+		printJSON(struct {
+			Pool      *big.Int `json:"pool"`
+			Hosts     *big.Int `json:"hosts"`
+			Fits      bool     `json:"fits"`
+			Remaining *big.Int `json:"remaining"`
+		}{pool, info.Hosts, fits, remaining})
+	} else if fits {
+		fmt.Printf("pool of %s fits; %s usable addresses remain (network and broadcast excluded)\n", pool, remaining)
+	} else {
+		fmt.Printf("pool of %s does not fit; %s has only %s usable addresses\n", pool, info.IPNet(), info.Hosts)
+	}
+
+	if !fits {
+		os.Exit(1)
+	}
+}
+
+// utilCommand reports what share of the usable addresses of a network are
+// in use and how many remain.
+func utilCommand(args []string) {
+	if len(args) != 3 || args[1] != "used" {
+		usageError()
+		return
+	}
+
+	info, err := calculate(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	used, ok := new(big.Int).SetString(args[2], 10)
+	if !ok || used.Sign() < 0 {
+		fail(fmt.Errorf("invalid used address count %q", args[2]))
+		return
+	}
+	if used.Cmp(info.Hosts) > 0 {
+		fail(fmt.Errorf("%s addresses used, but %s has only %s usable addresses", used, info.IPNet(), info.Hosts))
+		return
+	}
+
+	remaining := new(big.Int).Sub(info.Hosts, used)
+	percent := new(big.Rat).SetFrac(new(big.Int).Mul(used, big.NewInt(100)), info.Hosts)
+	if *jsonOutput {
+		value, _ := percent.Float64()
+		printJSON(struct {
+			Used      *big.Int `json:"used"`
+			Hosts     *big.Int `json:"hosts"`
+			Percent   float64  `json:"percent"`
+			Remaining *big.Int `json:"remaining"`
+		}{used, info.Hosts, value, remaining})
+		return
+	}
+	fmt.Printf("%s of %s usable addresses used (%s%%); %s remain\n", used, info.Hosts, percent.FloatString(1), remaining)
+}
+
+// alignedCommand prints whether the prefix of a network ends on an octet
+// boundary, and exits with status 1 when it does not.
+func alignedCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	info, err := calculate(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	aligned := ipcalc.IsOctetAligned(info.Prefix)
+	writeCheck(os.Stdout, "aligned", aligned)
+	if !aligned {
+		os.Exit(1)
+	}
+}
+
+// Exit statuses of validateCommand, telling an invalid address apart from an
+// invalid prefix length or mask. Other errors exit with status 1.
+const (
+	exitInvalidAddress = 3
+	exitInvalidPrefix  = 4
+)
+
+// validateCommand checks that its arguments form a valid network, printing
+// nothing and exiting with status 0 if they do.
+func validateCommand(args []string) {
+	if len(args) == 0 {
+		usageError()
+		return
+	}
+
+	input := strings.Join(args, " ")
+	address, prefix, hasPrefix := strings.Cut(input, "/")
+	if len(args) > 1 {
+		address = args[0]
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil {
+		failStatus(fmt.Errorf("invalid IP address %q", address), exitInvalidAddress)
+		return
+	}
+	if hasPrefix {
+		bits := 8 * net.IPv6len
+		if ip.To4() != nil && !strings.Contains(address, ":") {
+			bits = 8 * net.IPv4len
+		}
+		if n, err := strconv.Atoi(prefix); err != nil || n < 0 || n > bits {
+			failStatus(fmt.Errorf("invalid prefix length %q: must be between 0 and %d", prefix, bits), exitInvalidPrefix)
+			return
+		}
+	}
+
+	if _, err := calculate(input); err != nil {
+		status := 1
+		if len(args) > 1 || !hasPrefix {
+			// The address is fine, so the netmask or the classful default
+			// mask is what failed.
+			status = exitInvalidPrefix
+		}
+		failStatus(err, status)
+	}
+}
+
+// maxHosts is the number of addresses hostsCommand lists unless -force is
+// given.
+const maxHosts = 65536
+
+// hostsFlushEvery is how many addresses hostsCommand buffers before writing
+// them out.
+const hostsFlushEvery = 1024
+
+// hostsCommand prints every usable host address of a network, one per line.
+// With -hostname-prefix each address is followed by a numbered host name,
+// in the format of /etc/hosts, which dnsmasq also reads. With
+// -include-network-broadcast the list runs from the network address to the
+// broadcast address instead.
+func hostsCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	info, err := calculate(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if err := writeHosts(os.Stdout, info, *includeBounds); err != nil {
+		fail(err)
+	}
+}
+
+// writeHosts writes the usable host addresses of info to w, one per line, or
+// every address from the network to the broadcast address when
+// includeBounds is set.
+func writeHosts(out io.Writer, info ipcalc.Info, includeBounds bool) error {
+	first, last, count := info.HostMin, info.HostMax, info.Hosts
+	if includeBounds && info.Broadcast != nil {
+		first, last, count = info.Network, info.Broadcast, info.Total
+	}
+	if !*force && count.Cmp(big.NewInt(maxHosts)) > 0 {
+		return fmt.Errorf("%s has %s hosts, pass -force to list more than %d", networkString(info), count, maxHosts)
+	}
+
+	// Addresses are written as they are generated and flushed every
+	// hostsFlushEvery lines, so "ipcalc hosts 10.0.0.0/8 -force | head"
+	// shows output right away.
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	for i, ip := 1, first; ; i, ip = i+1, ipcalc.NextIP(ip) {
+		if *hostnamePrefix != "" {
+			fmt.Fprintf(w, "%s %s%d\n", ipString(info, ip), *hostnamePrefix, i)
+		} else {
+			fmt.Fprintln(w, ipString(info, ip))
+		}
+		if ip.Equal(last) {
+			break
+		}
+		if i%hostsFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+	return nil
+}
+
+// aggregateCommand prints the minimal set of networks covering the networks
+// given as arguments, or read one per line from stdin when there are none.
+func aggregateCommand(args []string) {
+	networks, err := readNetworks(args)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	printNetworks(ipcalc.Aggregate(networks))
+}
+
+// fromBoundsCommand calculates the network whose first and last addresses
+// are the given ones.
+func fromBoundsCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	first, last := net.ParseIP(args[0]), net.ParseIP(args[1])
+	for i, ip := range []net.IP{first, last} {
+		if ip == nil {
+			fail(fmt.Errorf("invalid IP address %q", args[i]))
+			return
+		}
+	}
+
+	network, err := ipcalc.FromBounds(first, last)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printResult(calculateNet(network))
+}
+
+// whichCommand prints the most specific of the given networks, or of those
+// read from stdin, that contains an address, and exits with status 1 when
+// none does.
+func whichCommand(args []string) {
+	if len(args) < 1 {
+		usageError()
+		return
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		fail(fmt.Errorf("invalid IP address %q", args[0]))
+		return
+	}
+	networks, err := readNetworks(args[1:])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	network, ok := ipcalc.LongestMatch(networks, ip)
+	if !ok {
+		fail(fmt.Errorf("%s is not in any of the networks", ip))
+		return
+	}
+	printNetwork(network)
+}
+
+// planChange is a network added to, removed from or changed in an address
+// plan, as printed by diffCommand. Only a change has a network it became.
+type planChange struct {
+	sign    string
+	network *net.IPNet
+	to      *net.IPNet
+}
+
+// diffCommand compares the address plans in two files of networks, and
+// prints the networks only in the first with a "-", those only in the
+// second with a "+", and those replaced by an overlapping network with a
+// "~", in address order.
+func diffCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	before, err := readNetworkFile(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	after, err := readNetworkFile(args[1])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	removed, added, changed := ipcalc.DiffNetworks(before, after)
+	if *jsonOutput {
+		type jsonChange struct {
+			Before string `json:"before"`
+			After  string `json:"after"`
+		}
+		changes := make([]jsonChange, 0, len(changed))
+		for _, c := range changed {
+			changes = append(changes, jsonChange{c.Before.String(), c.After.String()})
+		}
+		printJSON(struct {
+			Removed []string     `json:"removed"`
+			Added   []string     `json:"added"`
+			Changed []jsonChange `json:"changed"`
+		}{cidrStrings(removed), cidrStrings(added), changes})
+		return
+	}
+
+	lines := make([]planChange, 0, len(removed)+len(added)+len(changed))
+	for _, network := range removed {
+		lines = append(lines, planChange{"-", network, nil})
+	}
+	for _, network := range added {
+		lines = append(lines, planChange{"+", network, nil})
+	}
+	for _, c := range changed {
+		lines = append(lines, planChange{"~", c.Before, c.After})
+	}
+	sort.SliceStable(lines, func(i, j int) bool {
+		return ipcalc.LessNetwork(lines[i].network, lines[j].network)
+	})
+	for _, line := range lines {
+		if line.to != nil {
+			fmt.Printf("%s %s -> %s\n", line.sign, line.network, line.to)
+		} else {
+			fmt.Printf("%s %s\n", line.sign, line.network)
+		}
+	}
+}
+
+// rangeCommand prints the networks spanning an inclusive address range.
+func rangeCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	start, end := net.ParseIP(args[0]), net.ParseIP(args[1])
+	for i, ip := range []net.IP{start, end} {
+		if ip == nil {
+			fail(fmt.Errorf("invalid IP address %q", args[i]))
+			return
+		}
+	}
+
+	networks, err := ipcalc.RangeToCIDRs(start, end)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	printNetworks(networks)
+}
+
+// coverCommand prints the smallest network containing every address given
+// as an argument.
+func coverCommand(args []string) {
+	if len(args) == 0 {
+		usageError()
+		return
+	}
+
+	ips := make([]net.IP, 0, len(args))
+	for _, arg := range args {
+		ip := net.ParseIP(arg)
+		if ip == nil {
+			fail(fmt.Errorf("invalid IP address %q", arg))
+			return
+		}
+		ips = append(ips, ip)
+	}
+
+	network, err := ipcalc.Cover(ips)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printNetwork(network)
+}
+
+// overlapsCommand prints how two networks relate and exits with status 1 when
+// they do not overlap.
+func overlapsCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	networks, err := readNetworks(args)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	a, b := networks[0], networks[1]
+	relation := ipcalc.Overlaps(a, b)
+	if *jsonOutput {
+		printJSON(struct {
+			Relation string `json:"relation"`
+			Overlaps bool   `json:"overlaps"`
+		}{relation.String(), relation != ipcalc.Disjoint})
+	} else {
+		switch relation {
+		case ipcalc.Equal:
+			fmt.Printf("%s and %s are equal\n", a, b)
+		case ipcalc.AContainsB:
+			fmt.Printf("%s contains %s\n", a, b)
+		case ipcalc.BContainsA:
+			fmt.Printf("%s contains %s\n", b, a)
+		default:
+			fmt.Printf("%s and %s are disjoint\n", a, b)
+		}
+	}
+
+	if relation == ipcalc.Disjoint {
+		os.Exit(1)
+	}
+}
+
+// inCommand reports whether the first network lies entirely within the
+// second, and how many blocks of its size the second holds. It exits with
+// status 1 when it does not.
+func inCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	networks, err := readNetworks(args)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	child, parent := networks[0], networks[1]
+	relation := ipcalc.Overlaps(parent, child)
+	in := relation == ipcalc.AContainsB || relation == ipcalc.Equal
+	childOnes, _ := child.Mask.Size()
+	parentOnes, _ := parent.Mask.Size()
+	var count *big.Int
+	if in {
+		count, _ = ipcalc.SubnetCountBig(parentOnes, childOnes)
+	}
+
+	if *jsonOutput {
+		printJSON(struct {
+			In    bool     `json:"in"`
+			Count *big.Int `json:"count,omitempty"`
+		}{in, count})
+	} else if in {
+		fmt.Printf("%s is in %s, which holds %s /%d networks\n", child, parent, count, childOnes)
+	} else {
+		fmt.Printf("%s is not in %s\n", child, parent)
+	}
+
+	if !in {
+		os.Exit(1)
+	}
+}
+
+// vlsmCommand allocates subnets of a parent network for a list of host
+// requirements.
+func vlsmCommand(args []string) {
+	if len(args) < 2 {
+		usageError()
+		return
+	}
+
+	parent, err := ipcalc.ParseNetwork(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	hosts := make([]int, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		h, err := strconv.Atoi(arg)
+		if err != nil {
+			fail(fmt.Errorf("invalid host count %q", arg))
+			return
+		}
+		hosts = append(hosts, h)
+	}
+
+	allocations, err := ipcalc.VLSM(parent, hosts, *pointToPoint)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	if *jsonOutput {
+		type jsonAllocation struct {
+			Hosts   int      `json:"hosts"`
+			Network string   `json:"network"`
+			Usable  *big.Int `json:"usable"`
+		}
+		out := make([]jsonAllocation, 0, len(allocations))
+		for _, a := range allocations {
+			out = append(out, jsonAllocation{a.Hosts, a.Network.String(), ipcalc.UsableHosts(a.Network.Mask)})
+		}
+		printJSON(out)
+		return
+	}
+
+	renderAllocations(allocations, os.Stdout)
+}
+
+// deaggregateCommand prints the two halves of a network.
+func deaggregateCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	network, err := ipcalc.ParseNetwork(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	low, high, err := ipcalc.Halves(network)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printNetworks([]*net.IPNet{low, high})
+}
+
+// binaryCommand prints the binary form of an address.
+func binaryCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil {
+		fail(fmt.Errorf("invalid IP address %q", args[0]))
+		return
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if *annotate {
+		printValue(ipToAnnotatedBinaryString(ip, 0))
+		return
+	}
+	printValue(ipToBinaryString(ip, 0, binaryGroup))
+}
+
+// fromBinaryCommand prints the address written in binary form.
+func fromBinaryCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	ip, err := binaryStringToIP(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	printValue(ip.String())
+}
+
+// maskCommand converts between a prefix length, a dotted-decimal netmask and
+// a wildcard mask, and prints the host count that goes with them.
+func maskCommand(args []string) {
+	if len(args) != 1 && len(args) != 2 {
+		usageError()
+		return
+	}
+
+	mask, err := ipcalc.NormalizeMask(strings.Join(args, " "))
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	printMask(mask)
+}
+
+// fitHostsCommand prints the netmask of the smallest IPv4 network with at
+// least the given number of usable hosts.
+func fitHostsCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	hosts, err := strconv.Atoi(args[0])
+	if err != nil {
+		fail(fmt.Errorf("invalid host count %q", args[0]))
+		return
+	}
+	mask, err := ipcalc.MaskForHosts(hosts, *pointToPoint)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printMask(mask)
+}
+
+// fitSubnetsCommand prints the prefix length that divides a network into at
+// least the given number of subnets, and how many hosts each of them has.
+func fitSubnetsCommand(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	info, err := calculate(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		fail(fmt.Errorf("invalid subnet count %q", args[1]))
+		return
+	}
+	prefix, err := ipcalc.PrefixForSubnets(info.IPNet(), n)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	_, size := info.Mask.Size()
+	mask := net.CIDRMask(prefix, size)
+	subnets, _ := ipcalc.SubnetCountBig(info.Prefix, prefix)
+	hosts := ipcalc.UsableHosts(mask)
+	if *jsonOutput {
+		printJSON(struct {
+			Prefix  int      `json:"prefix"`
+			Netmask string   `json:"netmask"`
+			Subnets *big.Int `json:"subnets"`
+			Hosts   *big.Int `json:"hosts"`
+		}{prefix, net.IP(mask).String(), subnets, hosts})
+		return
+	}
+
+	if info.IsIPv6() {
+		fmt.Printf("Prefix:    /%d\n", prefix)
+	} else {
+		fmt.Printf("Prefix:    /%d = %s\n", prefix, net.IP(mask))
+	}
+	fmt.Printf("Subnets:   %s\n", subnets)
+	fmt.Printf("Hosts/Net: %s\n", hostsWithCount(hosts))
+}
+
+// printMask prints a netmask with its prefix length, wildcard and the number
+// of usable hosts of a network of that size.
+func printMask(mask net.IPMask) {
+	prefix, _ := ipcalc.MaskToPrefix(mask)
+	wildcard := ipcalc.Wildcard(mask)
+	hosts := ipcalc.UsableHosts(mask)
+	if *jsonOutput {
+		printJSON(struct {
+			Netmask  string   `json:"netmask"`
+			Prefix   int      `json:"prefix"`
+			Wildcard string   `json:"wildcard"`
+			Hosts    *big.Int `json:"hosts"`
+		}{net.IP(mask).String(), prefix, wildcard.String(), hosts})
+		return
+	}
+
+	writeRow(os.Stdout, "Netmask:", fmt.Sprintf("%s = %d", net.IP(mask), prefix), colorMask, ipv4Width, binary(net.IP(mask), prefix)+" "+maskAddresses(mask))
+	writeRow(os.Stdout, "Wildcard:", wildcard, "", ipv4Width, binary(wildcard, prefix))
+	fmt.Printf("Hosts/Net: %s\n", hosts)
+}
+
+// sixToFourCommand prints the 6to4 /48 prefix of an IPv4 address.
+func sixToFourCommand(args []string) {
+	ip := parseIPv4Arg(args)
+	if ip == nil {
+		return
+	}
+	printNetwork(&net.IPNet{IP: ipcalc.To6to4(ip), Mask: net.CIDRMask(48, 8*net.IPv6len)})
+}
+
+// mappedCommand prints the IPv4-mapped IPv6 address of an IPv4 address.
+func mappedCommand(args []string) {
+	ip := parseIPv4Arg(args)
+	if ip == nil {
+		return
+	}
+	// net.IP prints mapped addresses in dotted-decimal form, so write the
+	// ::ffff: prefix out by hand.
+	printValue("::ffff:" + ipcalc.ToMapped(ip).To4().String())
+}
+
+// eui64Command prints the address an interface with the given MAC address
+// configures for itself in an IPv6 network with SLAAC.
+func eui64Command(args []string) {
+	if len(args) != 2 {
+		usageError()
+		return
+	}
+
+	network, err := ipcalc.ParseNetwork(args[0])
+	if err != nil {
+		fail(err)
+		return
+	}
+	mac, err := net.ParseMAC(args[1])
+	if err != nil {
+		fail(fmt.Errorf("invalid MAC address %q", args[1]))
+		return
+	}
+
+	ip, err := ipcalc.EUI64Address(network, mac)
+	if err != nil {
+		fail(err)
+		return
+	}
+	printValue(ip.String())
+}
+
+// macCommand reports what the low bits of the first octet of a MAC address
+// say about it: whether it is sent to one or to a group of interfaces, and
+// whether it was assigned by the vendor whose OUI it starts with or by the
+// local administrator.
+func macCommand(args []string) {
+	if len(args) != 1 {
+		usageError()
+		return
+	}
+
+	mac, err := net.ParseMAC(args[0])
+	if err != nil || len(mac) != 6 {
+		fail(fmt.Errorf("invalid MAC address %q", args[0]))
+		return
+	}
+
+	multicast := mac[0]&0x01 != 0
+	local := mac[0]&0x02 != 0
+	broadcast := bytes.Equal(mac, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	oui := mac[:3].String()
+	if *jsonOutput {
+		if local {
+			oui = ""
+		}
+		printJSON(struct {
+			MAC       string `json:"mac"`
+			Multicast bool   `json:"multicast"`
+			Broadcast bool   `json:"broadcast"`
+			Local     bool   `json:"local"`
+			OUI       string `json:"oui,omitempty"`
+		}{mac.String(), multicast, broadcast, local, oui})
+		return
+	}
+
+	delivery := "unicast"
+	switch {
+	case broadcast:
+		delivery = "broadcast"
+	case multicast:
+		delivery = "multicast"
+	}
+	admin := fmt.Sprintf("globally administered (OUI %s)", oui)
+	if local {
+		admin = "locally administered"
+	}
+
+	fmt.Printf("MAC:       %s\n", mac)
+	fmt.Printf("Delivery:  %s\n", delivery)
+	fmt.Printf("Assigned:  %s\n", admin)
+}
+
+// parseIPv4Arg parses the single IPv4 address argument of a command. It
+// reports the error and returns nil if there is none.
+func parseIPv4Arg(args []string) net.IP {
+	if len(args) != 1 {
+		usageError()
+		return nil
+	}
+
+	ip := net.ParseIP(args[0])
+	if ip == nil || strings.Contains(args[0], ":") {
+		fail(fmt.Errorf("invalid IPv4 address %q", args[0]))
+		return nil
+	}
+	return ip
+}
+
+// sameNetCommand prints whether two addresses are in the same network under
+// a mask, and exits with status 1 when they are not.
+func sameNetCommand(args []string) {
+	if len(args) != 3 {
+		usageError()
+		return
+	}
+
+	a, b := net.ParseIP(args[0]), net.ParseIP(args[1])
+	for i, ip := range []net.IP{a, b} {
+		if ip == nil {
+			fail(fmt.Errorf("invalid IP address %q", args[i]))
+			return
+		}
+	}
+	if (a.To4() == nil) != (b.To4() == nil) {
+		fail(fmt.Errorf("%s and %s are not in the same address family", a, b))
+		return
+	}
+
+	var mask net.IPMask
+	var err error
+	if a.To4() != nil {
+		mask, err = ipcalc.NormalizeMask(args[2])
+	} else {
+		var prefix int
+		if prefix, err = parsePrefix(args[2]); err == nil && (prefix < 0 || prefix > 8*net.IPv6len) {
+			err = fmt.Errorf("prefix length /%d is out of range 0-128", prefix)
+		}
+		mask = net.CIDRMask(prefix, 8*net.IPv6len)
+	}
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	same := ipcalc.SameNetwork(a, b, mask)
+	writeCheck(os.Stdout, "sameNetwork", same)
+	if !same {
+		os.Exit(1)
+	}
+}
+
+// printValue prints a single result, or a JSON string in JSON mode.
+func printValue(s string) {
+	if *jsonOutput {
+		printJSON(s)
+		return
+	}
+	fmt.Println(s)
+}
+
+// writeCheck writes the answer of a true-or-false command, or with -json an
+// object holding it under name, such as {"contains": true}.
+func writeCheck(w io.Writer, name string, ok bool) {
+	if *jsonOutput {
+		writeJSON(w, map[string]bool{name: ok})
+		return
+	}
+	fmt.Fprintln(w, ok)
+}
+
+// printNetwork prints a network in CIDR notation, or as a JSON string.
+func printNetwork(network *net.IPNet) {
+	printValue(cidrString(network))
+}
+
+// cidrStrings returns networks in CIDR notation.
+func cidrStrings(networks []*net.IPNet) []string {
+	cidrs := make([]string, 0, len(networks))
+	for _, network := range networks {
+		cidrs = append(cidrs, cidrString(network))
+	}
+	return cidrs
+}
+
+// printNetworks prints networks in CIDR notation, one per line or as a JSON
+// array.
+func printNetworks(networks []*net.IPNet) {
+	if *jsonOutput {
+		printJSON(cidrStrings(networks))
+		return
+	}
+
+	for _, network := range networks {
+		fmt.Println(cidrString(network))
+	}
+}
+
+// readNetworks parses args as networks, or reads them one per line from
+// stdin if args is empty, skipping blank lines and comments.
+func readNetworks(args []string) ([]*net.IPNet, error) {
+	if len(args) == 0 {
+		return scanNetworks(os.Stdin)
+	}
+	return parseNetworks(args)
+}
+
+// readNetworkFile reads the networks listed one per line in a file,
+// skipping blank lines and comments.
+func readNetworkFile(path string) ([]*net.IPNet, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	networks, err := scanNetworks(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return networks, nil
+}
+
+// scanNetworks parses the networks listed one per line in r, skipping blank
+// lines and comments.
+func scanNetworks(r io.Reader) ([]*net.IPNet, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); !isComment(line) {
+			lines = append(lines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return parseNetworks(lines)
+}
+
+// parseNetworks parses each of args as a network.
+func parseNetworks(args []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(args))
+	for _, arg := range args {
+		network, err := ipcalc.ParseNetwork(arg)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+func TestWriteHosts(t *testing.T) {
+	tests := []struct {
+		cidr          string
+		family        ipcalc.Family
+		includeBounds bool
+		want          []string
+	}{
+		{"10.0.0.0/30", ipcalc.FamilyAuto, false, []string{"10.0.0.1", "10.0.0.2"}},
+		{"10.0.0.0/30", ipcalc.FamilyAuto, true, []string{"10.0.0.0", "10.0.0.1", "10.0.0.2", "10.0.0.3"}},
+		{"10.0.0.0/31", ipcalc.FamilyAuto, false, []string{"10.0.0.0", "10.0.0.1"}},
+		{"10.0.0.0/31", ipcalc.FamilyAuto, true, []string{"10.0.0.0", "10.0.0.1"}},
+		{"::ffff:10.0.0.0/126", ipcalc.FamilyIPv6, false, []string{"::ffff:10.0.0.0", "::ffff:10.0.0.1", "::ffff:10.0.0.2", "::ffff:10.0.0.3"}},
+	}
+
+	for _, tt := range tests {
+		info, err := ipcalc.CalculateFamily(tt.cidr, tt.family)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		if err := writeHosts(&buf, info, tt.includeBounds); err != nil {
+			t.Fatalf("writeHosts(%s, %v) failed: %v", tt.cidr, tt.includeBounds, err)
+		}
+		got := strings.Fields(buf.String())
+		if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+			t.Errorf("writeHosts(%s, %v) = %v, want %v", tt.cidr, tt.includeBounds, got, tt.want)
+		}
+	}
+}
+
+func TestCountSubnets(t *testing.T) {
+	info, err := ipcalc.Calculate("10.0.0.0/16")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := countSubnets(info, 24); err != nil || count.Int64() != 256 {
+		t.Errorf("countSubnets(/16, 24) = %v, %v, want 256", count, err)
+	}
+	for _, child := range []int{16, 8, 33} {
+		if count, err := countSubnets(info, child); err == nil {
+			t.Errorf("countSubnets(/16, %d) = %v, want an error", child, count)
+		}
+	}
+}
+
+func TestWriteCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+		json bool
+		want string
+	}{
+		{"contains", true, false, "true\n"},
+		{"contains", false, false, "false\n"},
+		{"contains", true, true, "{\n  \"contains\": true\n}\n"},
+		{"sameNetwork", false, true, "{\n  \"sameNetwork\": false\n}\n"},
+		{"aligned", true, true, "{\n  \"aligned\": true\n}\n"},
+	}
+
+	defer func(json bool) { *jsonOutput = json }(*jsonOutput)
+	for _, tt := range tests {
+		*jsonOutput = tt.json
+		var buf bytes.Buffer
+		writeCheck(&buf, tt.name, tt.ok)
+		if got := buf.String(); got != tt.want {
+			t.Errorf("writeCheck(%s, %v) with -json=%v = %q, want %q", tt.name, tt.ok, tt.json, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"net"
+	"os"
+	"strconv"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+var csvHeader = []string{"address", "netmask", "prefix", "wildcard", "network", "hostmin", "hostmax", "usablerange", "broadcast", "hosts", "class"}
+
+// newCSVWriter returns a CSV writer on out that has already written the
+// header row.
+func newCSVWriter(out io.Writer) *csv.Writer {
+	w := csv.NewWriter(out)
+	w.Write(csvHeader)
+	return w
+}
+
+func csvRecord(info ipcalc.Info) []string {
+	record := []string{
+		ipString(info, info.Address),
+		net.IP(info.Mask).String(),
+		strconv.Itoa(info.Prefix),
+		"",
+		ipString(info, info.Network),
+		ipString(info, info.HostMin),
+		ipString(info, info.HostMax),
+		usableRange(info),
+		"",
+		info.Hosts.String(),
+		"",
+	}
+
+	if !info.IsIPv6() {
+		record[3] = info.Wildcard.String()
+		record[8] = info.Broadcast.String()
+		record[10] = classLine(info)
+	}
+	if !info.HasHosts() {
+		record[5], record[6], record[7], record[9] = "", "", "", ""
+	}
+	return record
+}
+
+// printCSV prints infos as CSV, one row per network after the header.
+func printCSV(infos ...ipcalc.Info) {
+	w := newCSVWriter(os.Stdout)
+	for _, info := range infos {
+		w.Write(csvRecord(info))
+	}
+	w.Flush()
+}
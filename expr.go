@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// exprUsage documents the expressions understood on stdin.
+const exprUsage = `Lines read from stdin may also be expressions, printing one result each:
+       <IP>/<mask> + <N>          the network N blocks after
+       <IP>/<mask> - <N>          the network N blocks before
+       <IP>/<mask> contains <IP>  true or false
+       <IP>/<mask> split <N>      the N equally sized subnets`
+
+// exprVerbs are the operators of the expressions evaluated by evalExpr.
+var exprVerbs = map[string]func(network *net.IPNet, operand string) (string, error){
+	"+":        shiftExpr(1),
+	"-":        shiftExpr(-1),
+	"contains": containsExpr,
+	"split":    splitExpr,
+}
+
+// isExpr reports whether line is an expression rather than a network.
+func isExpr(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) != 3 {
+		return false
+	}
+	_, ok := exprVerbs[fields[1]]
+	return ok
+}
+
+// evalExpr evaluates an expression of the form "<network> <verb> <operand>".
+func evalExpr(line string) (string, error) {
+	fields := strings.Fields(line)
+	network, err := ipcalc.ParseNetwork(fields[0])
+	if err != nil {
+		return "", err
+	}
+	return exprVerbs[fields[1]](network, fields[2])
+}
+
+// shiftExpr returns an expression moving a network by a number of blocks of
+// its size, in the direction of sign.
+func shiftExpr(sign int64) func(*net.IPNet, string) (string, error) {
+	return func(network *net.IPNet, operand string) (string, error) {
+		k, err := strconv.ParseInt(operand, 10, 64)
+		if err != nil || k < 0 {
+			return "", fmt.Errorf("invalid block count %q", operand)
+		}
+
+		shifted, err := ipcalc.ShiftNetwork(network, sign*k)
+		if err != nil {
+			return "", err
+		}
+		return shifted.String(), nil
+	}
+}
+
+func containsExpr(network *net.IPNet, operand string) (string, error) {
+	ip := net.ParseIP(operand)
+	if ip == nil {
+		return "", fmt.Errorf("invalid IP address %q", operand)
+	}
+	return strconv.FormatBool(network.Contains(ip)), nil
+}
+
+func splitExpr(network *net.IPNet, operand string) (string, error) {
+	n, err := strconv.Atoi(operand)
+	if err != nil {
+		return "", fmt.Errorf("invalid subnet count %q", operand)
+	}
+
+	subnets, err := ipcalc.Split(network, n)
+	if err != nil {
+		return "", err
+	}
+
+	cidrs := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		cidrs = append(cidrs, subnet.String())
+	}
+	return strings.Join(cidrs, " "), nil
+}
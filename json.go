@@ -0,0 +1,123 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"math/big"
+	"net"
+	"os"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// jsonInfo is the machine-readable form of ipcalc.Info printed by -json.
+type jsonInfo struct {
+	Address         string   `json:"address"`
+	AddressBinary   string   `json:"addressBinary"`
+	AddressExpanded string   `json:"addressExpanded,omitempty"`
+	Netmask         string   `json:"netmask"`
+	NetmaskBinary   string   `json:"netmaskBinary"`
+	Prefix          int      `json:"prefix"`
+	Wildcard        string   `json:"wildcard,omitempty"`
+	WildcardBinary  string   `json:"wildcardBinary,omitempty"`
+	Network         string   `json:"network"`
+	NetworkBinary   string   `json:"networkBinary"`
+	NetworkExpanded string   `json:"networkExpanded,omitempty"`
+	HostMin         string   `json:"hostMin,omitempty"`
+	HostMinBinary   string   `json:"hostMinBinary,omitempty"`
+	HostMax         string   `json:"hostMax,omitempty"`
+	HostMaxBinary   string   `json:"hostMaxBinary,omitempty"`
+	UsableRange     string   `json:"usableRange,omitempty"`
+	Broadcast       string   `json:"broadcast,omitempty"`
+	BroadcastBinary string   `json:"broadcastBinary,omitempty"`
+	Gateway         string   `json:"gateway,omitempty"`
+	AddressInt      *big.Int `json:"addressInt"`
+	NetworkInt      *big.Int `json:"networkInt"`
+	BroadcastInt    *big.Int `json:"broadcastInt,omitempty"`
+	Hosts           *big.Int `json:"hosts,omitempty"`
+	TotalAddresses  *big.Int `json:"totalAddresses"`
+	Subnets64       *big.Int `json:"subnets64,omitempty"`
+	Class           string   `json:"class,omitempty"`
+	SubnetBits      int      `json:"subnetBits,omitempty"`
+	Privacy         string   `json:"privacy,omitempty"`
+	Scope           string   `json:"scope,omitempty"`
+	ReverseZones    []string `json:"reverseZones,omitempty"`
+	Note            string   `json:"note,omitempty"`
+}
+
+type jsonError struct {
+	Error string `json:"error"`
+}
+
+func newJSONInfo(info ipcalc.Info) jsonInfo {
+	out := jsonInfo{
+		Address:        ipString(info, info.Address),
+		AddressBinary:  ipToBinaryString(info.Address, 0, binaryGroup),
+		Netmask:        net.IP(info.Mask).String(),
+		NetmaskBinary:  ipToBinaryString(net.IP(info.Mask), 0, binaryGroup),
+		Prefix:         info.Prefix,
+		Network:        ipString(info, info.Network),
+		NetworkBinary:  ipToBinaryString(info.Network, 0, binaryGroup),
+		HostMin:        ipString(info, info.HostMin),
+		HostMinBinary:  ipToBinaryString(info.HostMin, 0, binaryGroup),
+		HostMax:        ipString(info, info.HostMax),
+		HostMaxBinary:  ipToBinaryString(info.HostMax, 0, binaryGroup),
+		UsableRange:    usableRange(info),
+		AddressInt:     ipInt(info, info.Address),
+		NetworkInt:     ipInt(info, info.Network),
+		Hosts:          info.Hosts,
+		TotalAddresses: info.Total,
+		Class:          info.Class,
+		Privacy:        info.Privacy,
+		Scope:          info.Scope,
+	}
+
+	if info.IsIPv6() {
+		out.AddressExpanded = ipcalc.ExpandIPv6(info.Address)
+		out.NetworkExpanded = ipcalc.ExpandIPv6(info.Network)
+		out.Subnets64, _ = subnets64(info)
+	}
+	if bits, ok := subnetBits(info); ok {
+		out.SubnetBits = bits
+	}
+	if info.Wildcard != nil {
+		out.Wildcard = info.Wildcard.String()
+		out.WildcardBinary = ipToBinaryString(info.Wildcard, 0, binaryGroup)
+	}
+	if info.Broadcast != nil {
+		out.Broadcast = info.Broadcast.String()
+		out.BroadcastBinary = ipToBinaryString(info.Broadcast, 0, binaryGroup)
+		out.BroadcastInt = ipcalc.IPToInt(info.Broadcast)
+		out.Gateway = gatewayFor(info).String()
+	}
+	if !info.HasHosts() {
+		// Multicast and reserved networks have no hosts, so the host
+		// fields are left out rather than filled with mask arithmetic.
+		out.HostMin, out.HostMinBinary = "", ""
+		out.HostMax, out.HostMaxBinary = "", ""
+		out.UsableRange, out.Hosts, out.Gateway = "", nil, ""
+		out.Note = noHostsNote(info)
+	}
+
+	return out
+}
+
+// newJSONOutput returns the JSON form of info, including the optional
+// sections selected by flags.
+func newJSONOutput(info ipcalc.Info) jsonInfo {
+	out := newJSONInfo(info)
+	if *reverse {
+		out.ReverseZones = ipcalc.ReverseDNSZones(info.IPNet())
+	}
+	return out
+}
+
+func printJSON(v any) {
+	writeJSON(os.Stdout, v)
+}
+
+func writeJSON(w io.Writer, v any) {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
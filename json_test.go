@@ -0,0 +1,22 @@
+package main
+
+import (
+	"testing"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+func TestJSONInfoMulticast(t *testing.T) {
+	info, err := ipcalc.Calculate("224.0.0.0/4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := newJSONInfo(info)
+	if out.Hosts != nil || out.HostMin != "" || out.HostMax != "" || out.Gateway != "" {
+		t.Errorf("got hosts %v, hostMin %q, hostMax %q, gateway %q, want them all left out", out.Hosts, out.HostMin, out.HostMax, out.Gateway)
+	}
+	if out.Note == "" {
+		t.Error("note is empty")
+	}
+}
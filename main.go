@@ -1,138 +1,401 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"math"
 	"net"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
+	"text/template"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
 )
 
-// Convert IP address to binary string representation
-func ipToBinaryString(ip net.IP) string {
-	binaryString := ""
-	for _, octet := range ip.To4() {
-		binaryString += fmt.Sprintf("%08b.", octet)
-	}
-	return strings.TrimRight(binaryString, ".")
+const usage = `Usage: ipcalc [flags] <IP>/<mask>...
+       ipcalc [flags] <IP>
+       ipcalc [flags] <IP> <netmask>|<prefix>
+       ipcalc [flags] <IP> wildcard <wildcard>
+       ipcalc [flags] <IP> /<prefix>-/<prefix>
+       ipcalc <IP>/<mask> contains <IP>
+       ipcalc <IP>/<mask> next|prev
+       ipcalc <IP>/<mask> count <prefix>
+       ipcalc <IP>/<mask> supernet <prefix>
+       ipcalc <IP>/<mask> locate <IP> <prefix>
+       ipcalc validate <IP>/<mask>
+       ipcalc aligned <IP>/<mask>
+       ipcalc dhcp <IP>/<mask> pool <size>
+       ipcalc util <IP>/<mask> used <count>
+       ipcalc host <IP>
+       ipcalc hosts <IP>/<mask>
+       ipcalc aggregate [<IP>/<mask>...]
+       ipcalc deaggregate <IP>/<mask>
+       ipcalc range <IP> <IP>
+       ipcalc frombounds <IP> <IP>
+       ipcalc cover <IP>...
+       ipcalc which <IP> [<IP>/<mask>...]
+       ipcalc diff <file> <file>
+       ipcalc overlaps <IP>/<mask> <IP>/<mask>
+       ipcalc in <IP>/<mask> <IP>/<mask>
+       ipcalc samenet <IP> <IP> <prefix>|<netmask>
+       ipcalc vlsm <IP>/<mask> <hosts>...
+       ipcalc binary <IP>
+       ipcalc frombinary <binary>
+       ipcalc mask <prefix>|<netmask>|wildcard <wildcard>
+       ipcalc fit-hosts <hosts>
+       ipcalc fit-subnets <IP>/<mask> <subnets>
+       ipcalc 6to4|mapped <IPv4>
+       ipcalc eui64 <IPv6>/<mask> <MAC>
+       ipcalc mac <MAC>`
+
+var (
+	jsonOutput = flag.Bool("json", false, "print the result as JSON")
+	csvOutput  = flag.Bool("csv", false, "print the result as CSV")
+	terraform  = flag.Bool("terraform", false, "print -split and -subnet results as an HCL list of CIDRs for Terraform")
+	jsonArray  = flag.Bool("json-array", false, "print -split and -subnet results as a JSON array of CIDRs")
+	split      = flag.Int("split", 0, "divide the network into `N` equally sized subnets")
+	subnet     = flag.String("subnet", "", "list every subnet of the network with the given `prefix` length")
+	reverse    = flag.Bool("reverse", false, "print the reverse DNS zones of the network")
+	force      = flag.Bool("force", false, "allow hosts, -split and -subnet to list more than 65536 entries")
+	strict     = flag.Bool("strict", false, "reject networks given with host bits set")
+	sortOutput = flag.Bool("sort", false, "print several networks ordered by address and prefix length")
+	quiet      = flag.Bool("quiet", false, "print only the network in CIDR notation")
+	countOnly  = flag.Bool("count", false, "print only the number of usable hosts")
+	oneline    = flag.Bool("oneline", false, "print the whole calculation on a single line")
+	neighbors  = flag.Bool("neighbors", false, "also summarize the networks of the same size before and after")
+	total      = flag.Bool("total", false, "also print the total number of addresses in the network")
+	annotate   = flag.Bool("annotate", false, "follow each group of binary digits with its value")
+	noColor    = flag.Bool("no-color", false, "disable colored output")
+	intOutput  = flag.Bool("int", false, "also print the integer value of the address, network and broadcast")
+	hexOutput  = flag.Bool("hex", false, "also print the address, netmask, network and broadcast in hexadecimal")
+	verbose    = flag.Bool("verbose", false, "also note when the address given is the network address, and classful zero and all-ones subnets")
+
+	showVersion    = flag.Bool("version", false, "print the version and build information and exit")
+	interactive    = flag.Bool("i", false, "calculate networks and expressions entered one per line, until quit")
+	includeBounds  = flag.Bool("include-network-broadcast", false, "make hosts also list the network and broadcast address")
+	format         = flag.String("format", "", "print each network with a Go text/template `template`, such as {{.Network}}/{{.Prefix}}")
+	groupFlag      = flag.String("binary-group", "octet", "group binary digits by `octet`, by nibble, or not at all")
+	familyFlag     = flag.String("family", "auto", "address `family` to interpret the input as: v4, v6 or auto")
+	gateway        = flag.String("gateway", "first", "suggest the `first` or last usable host as the gateway")
+	hostnamePrefix = flag.String("hostname-prefix", "", "make hosts print /etc/hosts entries named `prefix`1, prefix2, ...")
+	privateRanges  = flag.String("private-ranges", "", "comma-separated `CIDRs` to treat as private in addition to RFC 1918")
+	pointToPoint   = flag.Bool("point-to-point", false, "let vlsm and fit-hosts give 2 hosts a /31 and 1 host a /32 (RFC 3021)")
+)
+
+// Build metadata, set at build time with
+// -ldflags "-X main.version=... -X main.commit=... -X main.date=...".
+var (
+	version = "dev"
+	commit  = "unknown"
+	date    = "unknown"
+)
+
+// family is the address family selected with -family.
+var family ipcalc.Family
+
+// binaryGroup is the grouping of binary digits selected with -binary-group.
+var binaryGroup binaryGrouping
+
+// outputTemplate is the template given with -format, if any.
+var outputTemplate *template.Template
+
+func init() {
+	flag.BoolVar(quiet, "n", false, "shorthand for -quiet")
 }
 
-// Calculate the network, broadcast, and range of host IP addresses
-func calculateNetworkInfo(ip net.IP, mask net.IPMask) (net.IP, net.IP, net.IP, net.IP) {
-	network := ip.Mask(mask)
-	broadcast := make(net.IP, len(network))
-	copy(broadcast, network)
-	for i := range broadcast {
-		broadcast[i] |= ^mask[i]
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+		fmt.Fprintln(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), exprUsage)
+	}
+	args := parseArgs(os.Args[1:])
+	colorEnabled = useColor()
+
+	if *showVersion {
+		fmt.Printf("ipcalc %s (commit %s, built %s)\n", version, commit, date)
+		return
+	}
+
+	var err error
+	if family, err = ipcalc.ParseFamily(*familyFlag); err != nil {
+		fail(err)
+		return
+	}
+
+	if binaryGroup, err = parseGrouping(*groupFlag); err != nil {
+		fail(err)
+		return
+	}
+
+	if *format != "" {
+		if outputTemplate, err = template.New("format").Parse(*format); err != nil {
+			fail(fmt.Errorf("-format: %w", err))
+			return
+		}
+	}
+
+	if *gateway != "first" && *gateway != "last" {
+		fail(fmt.Errorf("-gateway must be first or last, not %q", *gateway))
+		return
+	}
+
+	if *privateRanges != "" {
+		if err := addPrivateRanges(*privateRanges); err != nil {
+			fail(err)
+			return
+		}
+	}
+
+	if *interactive {
+		runInteractive()
+		return
+	}
+
+	if len(args) >= 1 {
+		if command, ok := commands[args[0]]; ok {
+			command(args[1:])
+			return
+		}
+	}
+	if len(args) >= 2 {
+		if command, ok := networkCommands[args[1]]; ok {
+			info, err := calculate(args[0])
+			if err != nil {
+				fail(err)
+				return
+			}
+			command(info, args[2:])
+			return
+		}
+	}
+
+	if len(args) == 1 && args[0] == "-" || len(args) == 0 && stdinIsPipe() {
+		calculateBatch(os.Stdin)
+		return
+	}
+
+	inputs, err := splitInputs(args)
+	if err != nil {
+		fail(err)
+		return
+	}
+	if len(inputs) == 0 {
+		usageError()
+		return
+	}
+
+	if len(inputs) > 1 {
+		b := newBatch()
+		for _, input := range inputs {
+			b.add(calculate(input))
+		}
+		b.finish()
+		return
 	}
 
-	hostMin := make(net.IP, len(network))
-	copy(hostMin, network)
-	hostMin[len(hostMin)-1]++
+	info, err := calculate(inputs[0])
+	if err != nil {
+		fail(err)
+		return
+	}
 
-	hostMax := make(net.IP, len(broadcast))
-	copy(hostMax, broadcast)
-	hostMax[len(hostMax)-1]--
+	if *subnet != "" {
+		enumerateNetwork(info, *subnet)
+		return
+	}
 
-	return network, broadcast, hostMin, hostMax
+	if *split > 0 {
+		splitNetwork(info, *split)
+		return
+	}
+
+	printResult(info)
 }
 
-// Determine the class of the network
-func getClass(ip net.IP) string {
-	firstOctet := ip[0]
-	var class, privacy string
-
-	switch {
-	case firstOctet <= 127:
-		class = "Class A"
-	case firstOctet >= 128 && firstOctet <= 191:
-		class = "Class B"
-	case firstOctet >= 192 && firstOctet <= 223:
-		class = "Class C"
-	case firstOctet >= 224 && firstOctet <= 239:
-		class = "Class D (Multicast)"
-	default:
-		class = "Class E (Reserved)"
-	}
-
-	if isPrivate(ip) {
-		privacy = "Private Internet"
-	} else {
-		privacy = "Public Internet"
+// printResult prints the calculation for a single network in the output
+// format selected by the flags.
+func printResult(info ipcalc.Info) {
+	if *jsonOutput {
+		printJSON(newJSONOutput(info))
+		return
 	}
 
-	return fmt.Sprintf("%s, %s", class, privacy)
+	if *csvOutput {
+		printCSV(info)
+		return
+	}
+
+	printInfo(info)
 }
 
-// Determine if the network is private
-func isPrivate(ip net.IP) bool {
+// parseArgs parses the command-line flags and returns the positional
+// arguments. Unlike flag.Parse it also accepts flags after the first
+// positional argument, as in "ipcalc 10.0.0.0/24 -json", until a "--".
+func parseArgs(args []string) []string {
+	var positional []string
+	for len(args) > 0 {
+		if args[0] == "--" {
+			return append(positional, args[1:]...)
+		}
+		if args[0] == "-" || !strings.HasPrefix(args[0], "-") {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+
+		flag.CommandLine.Parse(args)
+		rest := flag.Args()
+		if n := len(args) - len(rest); args[n-1] == "--" {
+			return append(positional, rest...)
+		}
+		args = rest
+	}
+	return positional
+}
 
-	privateRanges := []struct {
-		network *net.IPNet
-	}{
-		{parseCIDR("10.0.0.0/8")},
-		{parseCIDR("172.16.0.0/12")},
-		{parseCIDR("192.168.0.0/16")},
+// calculate calculates input in the address family selected with -family.
+func calculate(input string) (ipcalc.Info, error) {
+	info, err := ipcalc.CalculateFamily(input, family)
+	if err == nil && *strict && info.HostBitsSet() {
+		return info, fmt.Errorf("%q has host bits set, the network address is %s", input, info.IPNet())
 	}
-	for _, r := range privateRanges {
-		if r.network.Contains(ip) {
-			return true
+	return info, err
+}
+
+// addPrivateRanges parses a comma-separated list of networks and adds them to
+// the ranges classified as private.
+func addPrivateRanges(list string) error {
+	var networks []*net.IPNet
+	for _, cidr := range strings.Split(list, ",") {
+		network, err := ipcalc.ParseNetwork(strings.TrimSpace(cidr))
+		if err != nil {
+			return fmt.Errorf("-private-ranges: %w", err)
 		}
+		networks = append(networks, network)
 	}
-	return false
+
+	ipcalc.AddPrivateRanges(networks...)
+	return nil
 }
 
-func parseCIDR(cidr string) *net.IPNet {
-	_, network, _ := net.ParseCIDR(cidr)
-	return network
+// maxPrefixSpan limits how many prefix lengths a prefix range may cover.
+const maxPrefixSpan = 16
+
+// prefixRangePattern matches a range of prefix lengths such as /24-/27.
+var prefixRangePattern = regexp.MustCompile(`^/?(\d+)-/?(\d+)$`)
+
+// splitInputs groups positional arguments into networks. An address without
+// a prefix takes the following netmask or prefix length, written as 24 or
+// /24, or "wildcard" and a wildcard mask, along with it. Any other argument
+// after it is a network of its own. An address followed by a prefix range
+// such as /24-/27 is expanded into one network per prefix length.
+func splitInputs(args []string) ([]string, error) {
+	var inputs []string
+	for i := 0; i < len(args); i++ {
+		bare := !strings.Contains(args[i], "/")
+		switch {
+		case bare && i+1 < len(args) && prefixRangePattern.MatchString(args[i+1]):
+			expanded, err := expandPrefixRange(args[i], args[i+1])
+			if err != nil {
+				return nil, err
+			}
+			inputs = append(inputs, expanded...)
+			i++
+		case bare && i+2 < len(args) && args[i+1] == "wildcard":
+			inputs = append(inputs, strings.Join(args[i:i+3], " "))
+			i += 2
+		case bare && i+1 < len(args) && isMaskArg(args[i+1]):
+			inputs = append(inputs, args[i]+" "+strings.TrimPrefix(args[i+1], "/"))
+			i++
+		default:
+			inputs = append(inputs, args[i])
+		}
+	}
+	return inputs, nil
 }
 
-func main() {
-	if len(os.Args) != 2 {
-		fmt.Println("Usage: ipcalc <IP>/<mask>")
-		return
+// isMaskArg reports whether arg is a netmask or a prefix length, with or
+// without its leading slash, and so belongs to the bare address before it
+// rather than being a network of its own.
+func isMaskArg(arg string) bool {
+	if _, err := strconv.ParseUint(strings.TrimPrefix(arg, "/"), 10, 8); err == nil {
+		return true
+	}
+	_, err := ipcalc.ParseMask(arg)
+	return err == nil
+}
+
+// expandPrefixRange returns address in CIDR notation at every prefix length
+// of the range spec.
+func expandPrefixRange(address, spec string) ([]string, error) {
+	m := prefixRangePattern.FindStringSubmatch(spec)
+	from, _ := strconv.Atoi(m[1])
+	to, _ := strconv.Atoi(m[2])
+	if from > to {
+		return nil, fmt.Errorf("invalid prefix range %q: the first prefix must be the shorter one", spec)
+	}
+	if to-from >= maxPrefixSpan {
+		return nil, fmt.Errorf("invalid prefix range %q: more than %d prefix lengths", spec, maxPrefixSpan)
 	}
 
-	ip, ipNet, err := net.ParseCIDR(os.Args[1])
-	if err != nil {
-		fmt.Println("Invalid CIDR notation")
-		return
+	inputs := make([]string, 0, to-from+1)
+	for prefix := from; prefix <= to; prefix++ {
+		inputs = append(inputs, fmt.Sprintf("%s/%d", address, prefix))
 	}
+	return inputs, nil
+}
 
-	networkIp := ipNet.IP
-	mask := ipNet.Mask
-	network, broadcast, hostMin, hostMax := calculateNetworkInfo(networkIp, mask)
+// fail reports err in the selected output format and exits with status 1.
+// Errors go to stderr, except in JSON mode where an error object is printed
+// in place of the result.
+func fail(err error) {
+	failStatus(err, 1)
+}
 
-	netmaskFmt := fmt.Sprintf("%s = %d", net.IP(mask), maskSize(mask))
-	networkFmt := fmt.Sprintf("%s /%d", network, maskSize(mask))
+// failStatus is like fail but exits with the given status.
+func failStatus(err error, status int) {
+	if *jsonOutput {
+		printJSON(jsonError{Error: err.Error()})
+	} else {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(status)
+}
 
-	fmt.Printf("Address:   %-20s %s\n", ip, ipToBinaryString(networkIp))
-	fmt.Printf("Netmask:   %-20s %s\n", netmaskFmt, ipToBinaryString(net.IP(mask)))
-	fmt.Printf("Wildcard:  %-20s %s\n", wildcard(mask), ipToBinaryString(wildcard(mask)))
-	fmt.Println("=>")
-	fmt.Printf("Network:   %-20s %s\n", networkFmt, ipToBinaryString(network))
-	fmt.Printf("HostMin:   %-20s %s\n", hostMin, ipToBinaryString(hostMin))
-	fmt.Printf("HostMax:   %-20s %s\n", hostMax, ipToBinaryString(hostMax))
-	fmt.Printf("Broadcast: %-20s %s\n", broadcast, ipToBinaryString(broadcast))
-	fmt.Printf("Hosts/Net: %-20d %s\n", hostsPerNetwork(mask), getClass(networkIp))
+// usageError prints the usage message to stderr and exits with status 2.
+func usageError() {
+	flag.Usage()
+	os.Exit(2)
 }
 
-// Helper functions
+func splitNetwork(info ipcalc.Info, n int) {
+	newPrefix, err := ipcalc.SplitPrefix(info.IPNet(), n)
+	if err != nil {
+		fail(err)
+		return
+	}
 
-func maskSize(mask net.IPMask) int {
-	ones, _ := mask.Size()
-	return ones
+	printSubnets(info.IPNet(), newPrefix)
 }
 
-func wildcard(mask net.IPMask) net.IP {
-	wildcard := make(net.IP, len(mask))
-	for i := range mask {
-		wildcard[i] = ^mask[i]
+func enumerateNetwork(info ipcalc.Info, prefix string) {
+	newPrefix, err := parsePrefix(prefix)
+	if err != nil {
+		fail(err)
+		return
 	}
-	return wildcard
+
+	printSubnets(info.IPNet(), newPrefix)
 }
 
-func hostsPerNetwork(mask net.IPMask) int {
-	ones, bits := mask.Size()
-	return int(math.Pow(2, float64(bits-ones)) - 2)
+// parsePrefix parses a prefix length written as "24" or "/24".
+func parsePrefix(s string) (int, error) {
+	prefix, err := strconv.Atoi(strings.TrimPrefix(s, "/"))
+	if err != nil {
+		return 0, fmt.Errorf("invalid prefix length %q", s)
+	}
+	return prefix, nil
 }
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitInputs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"10.0.0.1", "172.16.0.1"}, []string{"10.0.0.1", "172.16.0.1"}},
+		{[]string{"192.168.1.0", "255.255.255.0"}, []string{"192.168.1.0 255.255.255.0"}},
+		{[]string{"192.168.1.0", "24", "10.0.0.1"}, []string{"192.168.1.0 24", "10.0.0.1"}},
+		{[]string{"192.168.1.0", "/24"}, []string{"192.168.1.0 24"}},
+		{[]string{"2001:db8::", "64"}, []string{"2001:db8:: 64"}},
+		{[]string{"10.0.0.0", "wildcard", "0.0.0.255"}, []string{"10.0.0.0 wildcard 0.0.0.255"}},
+		{[]string{"10.0.0.0/24", "192.168.1.0/25"}, []string{"10.0.0.0/24", "192.168.1.0/25"}},
+	}
+
+	for _, tt := range tests {
+		got, err := splitInputs(tt.args)
+		if err != nil {
+			t.Errorf("splitInputs(%q) failed: %v", tt.args, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitInputs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package ipcalc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Aggregate returns the smallest set of networks that covers exactly the same
+// addresses as networks. Contained networks are dropped and adjacent sibling
+// networks are merged into their parent. The result is sorted with IPv4
+// networks first.
+//
+// The networks are sorted once and then merged in a single pass, keeping
+// the result so far as a stack whose top is merged with each new network,
+// so large lists take O(n log n) time.
+func Aggregate(networks []*net.IPNet) []*net.IPNet {
+	sorted := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		sorted = append(sorted, canonicalNet(network))
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return lessNet(sorted[i], sorted[j])
+	})
+
+	merged := make([]*net.IPNet, 0, len(sorted))
+	for _, network := range sorted {
+		if n := len(merged); n > 0 && containsNet(merged[n-1], network) {
+			continue
+		}
+
+		merged = append(merged, network)
+		for n := len(merged); n >= 2; n = len(merged) {
+			parent, ok := mergeSiblings(merged[n-2], merged[n-1])
+			if !ok {
+				break
+			}
+			merged = append(merged[:n-2], parent)
+		}
+	}
+	return merged
+}
+
+// Cover returns the smallest single network containing every address in ips.
+// Unlike Aggregate, the result may include addresses that are not in ips.
+// The addresses must all be of the same family.
+func Cover(ips []net.IP) (*net.IPNet, error) {
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("no addresses to cover")
+	}
+
+	first := ips[0].To4()
+	if first == nil {
+		first = ips[0]
+	}
+	size := 8 * len(first)
+
+	// The network keeps the leading bits shared by every address, which end
+	// where the highest bit differing from the first address is.
+	base := IPToInt(first)
+	hostBits := 0
+	for _, ip := range ips[1:] {
+		if (ip.To4() == nil) != (len(first) == net.IPv6len) {
+			return nil, fmt.Errorf("%s and %s are not of the same address family", ips[0], ip)
+		}
+		if n := new(big.Int).Xor(base, IPToInt(ip)).BitLen(); n > hostBits {
+			hostBits = n
+		}
+	}
+
+	mask := net.CIDRMask(size-hostBits, size)
+	return &net.IPNet{IP: first.Mask(mask), Mask: mask}, nil
+}
+
+// FromBounds returns the network whose network and broadcast addresses, or
+// first and last addresses for IPv6, are exactly first and last, as
+// 192.168.1.0/24 is for 192.168.1.0 and 192.168.1.255. It fails if no single
+// network spans that range.
+func FromBounds(first, last net.IP) (*net.IPNet, error) {
+	networks, err := RangeToCIDRs(first, last)
+	if err != nil {
+		return nil, err
+	}
+	if len(networks) != 1 {
+		return nil, fmt.Errorf("%s - %s is not a single network: it takes %d networks to cover", first, last, len(networks))
+	}
+	return networks[0], nil
+}
+
+// RangeToCIDRs returns the smallest list of networks that spans exactly the
+// inclusive range from start to end. Both addresses must be of the same
+// family and start must not be greater than end.
+func RangeToCIDRs(start, end net.IP) ([]*net.IPNet, error) {
+	if start4, end4 := start.To4(), end.To4(); (start4 == nil) != (end4 == nil) {
+		return nil, fmt.Errorf("%s and %s are not of the same address family", start, end)
+	} else if start4 != nil {
+		start, end = start4, end4
+	}
+
+	size := 8 * len(start)
+	cur, last := IPToInt(start), IPToInt(end)
+	if cur.Cmp(last) > 0 {
+		return nil, fmt.Errorf("start address %s is greater than end address %s", start, end)
+	}
+
+	var networks []*net.IPNet
+	one := big.NewInt(1)
+	for cur.Cmp(last) <= 0 {
+		hostBits := size
+		if cur.Sign() != 0 {
+			hostBits = int(cur.TrailingZeroBits())
+		}
+
+		blockEnd := new(big.Int)
+		for {
+			blockEnd.Lsh(one, uint(hostBits)).Add(blockEnd, cur).Sub(blockEnd, one)
+			if blockEnd.Cmp(last) <= 0 {
+				break
+			}
+			hostBits--
+		}
+
+		networks = append(networks, &net.IPNet{
+			IP:   intToIP(cur, len(start)),
+			Mask: net.CIDRMask(size-hostBits, size),
+		})
+		cur = blockEnd.Add(blockEnd, one)
+	}
+	return networks, nil
+}
+
+// canonicalNet returns network with its host bits cleared and, for IPv4, a
+// 4-byte address.
+func canonicalNet(network *net.IPNet) *net.IPNet {
+	ip := network.IP
+	if _, size := network.Mask.Size(); size == 8*net.IPv4len {
+		ip = ip.To4()
+	}
+	return &net.IPNet{IP: ip.Mask(network.Mask), Mask: network.Mask}
+}
+
+// LessNetwork reports whether network a sorts before b: IPv4 before IPv6,
+// then by the integer value of the network address, and then by prefix
+// length, so that a network comes right before its subnets.
+func LessNetwork(a, b *net.IPNet) bool {
+	return lessNet(canonicalNet(a), canonicalNet(b))
+}
+
+// lessNet orders canonical networks by family, then address, then prefix
+// length.
+func lessNet(a, b *net.IPNet) bool {
+	if len(a.IP) != len(b.IP) {
+		return len(a.IP) < len(b.IP)
+	}
+	if c := bytes.Compare(a.IP, b.IP); c != 0 {
+		return c < 0
+	}
+	return maskSize(a.Mask) < maskSize(b.Mask)
+}
+
+// containsNet reports whether canonical network a contains all of b.
+func containsNet(a, b *net.IPNet) bool {
+	return len(a.IP) == len(b.IP) && maskSize(a.Mask) <= maskSize(b.Mask) && a.Contains(b.IP)
+}
+
+// mergeSiblings returns the parent of a and b if they are the two halves of
+// it, in order.
+func mergeSiblings(a, b *net.IPNet) (*net.IPNet, bool) {
+	ones, size := a.Mask.Size()
+	if len(a.IP) != len(b.IP) || ones == 0 || ones != maskSize(b.Mask) {
+		return nil, false
+	}
+
+	mask := net.CIDRMask(ones-1, size)
+	if !a.IP.Mask(mask).Equal(a.IP) || !b.IP.Mask(mask).Equal(a.IP) || a.IP.Equal(b.IP) {
+		return nil, false
+	}
+	return &net.IPNet{IP: a.IP, Mask: mask}, true
+}
@@ -0,0 +1,46 @@
+package ipcalc
+
+import (
+	"math/rand"
+	"net"
+	"testing"
+)
+
+// consecutiveSubnets returns n consecutive /24s starting at 10.0.0.0, in a
+// fixed shuffled order.
+func consecutiveSubnets(n int) []*net.IPNet {
+	networks := make([]*net.IPNet, n)
+	for i := range networks {
+		networks[i] = &net.IPNet{
+			IP:   net.IPv4(10, byte(i>>8), byte(i), 0).To4(),
+			Mask: net.CIDRMask(24, 32),
+		}
+	}
+	rand.New(rand.NewSource(1)).Shuffle(n, func(i, j int) {
+		networks[i], networks[j] = networks[j], networks[i]
+	})
+	return networks
+}
+
+func TestAggregateLargeList(t *testing.T) {
+	got := Aggregate(consecutiveSubnets(50000))
+
+	// 50000 /24s are 32768 + 16384 + 512 + 256 + 64 + 16 of them.
+	want := []string{"10.0.0.0/9", "10.128.0.0/10", "10.192.0.0/15", "10.194.0.0/16", "10.195.0.0/18", "10.195.64.0/20"}
+	if len(got) != len(want) {
+		t.Fatalf("Aggregate returned %d networks %v, want %v", len(got), got, want)
+	}
+	for i := range want {
+		if got[i].String() != want[i] {
+			t.Errorf("Aggregate()[%d] = %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func BenchmarkAggregate(b *testing.B) {
+	networks := consecutiveSubnets(50000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Aggregate(networks)
+	}
+}
@@ -0,0 +1,188 @@
+package ipcalc
+
+import (
+	"fmt"
+	"net"
+)
+
+// Class returns the classful network class of an IPv4 address. Both the 4
+// and 16-byte forms are accepted; other addresses have no class.
+func Class(ip net.IP) string {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return "n/a (classful addressing is IPv4-only)"
+	}
+	firstOctet := ip4[0]
+
+	switch {
+	case firstOctet <= 127:
+		return "Class A"
+	case firstOctet >= 128 && firstOctet <= 191:
+		return "Class B"
+	case firstOctet >= 192 && firstOctet <= 223:
+		return "Class C"
+	case firstOctet >= 224 && firstOctet <= 239:
+		return "Class D (Multicast)"
+	default:
+		return "Class E (Reserved)"
+	}
+}
+
+// labeledRange is a network together with the label it is reported as.
+type labeledRange struct {
+	network *net.IPNet
+	label   string
+}
+
+// privateRanges are the RFC 1918 private blocks plus the RFC 6598 shared
+// address space used by carrier-grade NAT.
+var privateRanges = []labeledRange{
+	{parseCIDR("10.0.0.0/8"), "Private"},
+	{parseCIDR("172.16.0.0/12"), "Private"},
+	{parseCIDR("192.168.0.0/16"), "Private"},
+	{parseCIDR("100.64.0.0/10"), "CGNAT Shared Address Space"},
+}
+
+// ClassfulPrefix returns the default prefix length of the class of an IPv4
+// address: /8 for class A, /16 for class B and /24 for class C. Multicast
+// and reserved addresses have no default mask.
+func ClassfulPrefix(ip net.IP) (int, error) {
+	switch Class(ip) {
+	case "Class A":
+		return 8, nil
+	case "Class B":
+		return 16, nil
+	case "Class C":
+		return 24, nil
+	default:
+		return 0, fmt.Errorf("%w: %s has no classful default mask (%s)", ErrInvalidCIDR, ip, Class(ip))
+	}
+}
+
+// AddPrivateRanges extends the ranges reported as private by Privacy and
+// IsPrivate, for organizations with internal addressing outside RFC 1918.
+func AddPrivateRanges(networks ...*net.IPNet) {
+	for _, network := range networks {
+		privateRanges = append(privateRanges, labeledRange{canonicalNet(network), "Private"})
+	}
+}
+
+// Privacy returns whether the address belongs to the private or the public
+// internet. Private addresses are reported with the block they belong to, as
+// in "Private (10.0.0.0/8)".
+func Privacy(ip net.IP) string {
+	if r, ok := privateRange(ip); ok {
+		return fmt.Sprintf("%s (%s)", r.label, r.network)
+	}
+	return "Public Internet"
+}
+
+// NetworkPrivacy is like Privacy, but considers every address of network
+// rather than only its first one. A network that holds both private and
+// public addresses, such as the 192.0.0.0/8 supernet of 192.168.0.0/16, is
+// "Mixed (private and public)".
+func NetworkPrivacy(network *net.IPNet) string {
+	for _, r := range privateRanges {
+		if rel := Overlaps(r.network, network); rel == AContainsB || rel == Equal {
+			return fmt.Sprintf("%s (%s)", r.label, r.network)
+		}
+	}
+	for _, r := range privateRanges {
+		if Overlaps(r.network, network) != Disjoint {
+			return "Mixed (private and public)"
+		}
+	}
+	return "Public Internet"
+}
+
+// IsPrivate reports whether the address is in one of the RFC 1918 ranges or
+// in the CGNAT shared address space.
+func IsPrivate(ip net.IP) bool {
+	_, ok := PrivateBlock(ip)
+	return ok
+}
+
+// PrivateBlock returns the private range containing ip, such as
+// 192.168.0.0/16 for 192.168.1.1, and whether there is one.
+func PrivateBlock(ip net.IP) (*net.IPNet, bool) {
+	r, ok := privateRange(ip)
+	return r.network, ok
+}
+
+func privateRange(ip net.IP) (labeledRange, bool) {
+	for _, r := range privateRanges {
+		if r.network.Contains(ip) {
+			return r, true
+		}
+	}
+	return labeledRange{}, false
+}
+
+// specialRanges are IPv4 special-use blocks (RFC 6890) that are neither
+// private nor public in the usual sense.
+var specialRanges = []labeledRange{
+	{parseCIDR("0.0.0.0/8"), "This Network"},
+	{parseCIDR("127.0.0.0/8"), "Loopback"},
+	{parseCIDR("169.254.0.0/16"), "Link-Local"},
+	{parseCIDR("192.0.2.0/24"), "Documentation (TEST-NET-1)"},
+	{parseCIDR("198.51.100.0/24"), "Documentation (TEST-NET-2)"},
+	{parseCIDR("203.0.113.0/24"), "Documentation (TEST-NET-3)"},
+}
+
+// ClassifyScope returns the label of the most specific special-use range
+// containing ip, or an empty string if the address is not special.
+func ClassifyScope(ip net.IP) string {
+	return mostSpecificLabel(specialRanges, ip)
+}
+
+// ipv6Ranges are the IPv6 address types (RFC 4291 and RFC 4193) reported by
+// ClassifyIPv6.
+var ipv6Ranges = []labeledRange{
+	{parseCIDR("::/128"), "Unspecified"},
+	{parseCIDR("::1/128"), "Loopback"},
+	{parseCIDR("::ffff:0:0/96"), "IPv4-Mapped"},
+	{parseCIDR("2000::/3"), "Global Unicast"},
+	{parseCIDR("fc00::/7"), "Unique Local (ULA)"},
+	{parseCIDR("fe80::/10"), "Link-Local"},
+	{parseCIDR("ff00::/8"), "Multicast"},
+}
+
+// ClassifyIPv6 returns the type of an IPv6 address, such as "Global
+// Unicast" or "Link-Local", the IPv6 counterpart of Class. Addresses outside
+// the allocated types are "Reserved".
+func ClassifyIPv6(ip net.IP) string {
+	if label := mostSpecificLabel(ipv6Ranges, ip); label != "" {
+		return label
+	}
+	return "Reserved"
+}
+
+// mostSpecificLabel returns the label of the longest of ranges containing
+// ip, or an empty string if none does.
+func mostSpecificLabel(ranges []labeledRange, ip net.IP) string {
+	label, longest := "", -1
+	for _, r := range ranges {
+		if ones := maskSize(r.network.Mask); r.network.Contains(ip) && ones > longest {
+			label, longest = r.label, ones
+		}
+	}
+	return label
+}
+
+// OverlappingScopes returns the labels of the special-use ranges that
+// overlap network, either because they contain it or because it contains
+// them.
+func OverlappingScopes(network *net.IPNet) []string {
+	var labels []string
+	for _, r := range specialRanges {
+		if Overlaps(network, r.network) != Disjoint {
+			labels = append(labels, r.label)
+		}
+	}
+	return labels
+}
+
+func parseCIDR(cidr string) *net.IPNet {
+	_, network, _ := net.ParseCIDR(cidr)
+	return network
+}
@@ -0,0 +1,32 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestClass(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"10.1.2.3", "Class A"},
+		{"172.16.0.1", "Class B"},
+		{"192.168.1.1", "Class C"},
+		{"224.0.0.1", "Class D (Multicast)"},
+		{"240.0.0.1", "Class E (Reserved)"},
+	}
+
+	for _, tt := range tests {
+		ip := net.ParseIP(tt.address)
+		for _, form := range []net.IP{ip.To4(), ip.To16()} {
+			if got := Class(form); got != tt.want {
+				t.Errorf("Class(%s in %d bytes) = %q, want %q", tt.address, len(form), got, tt.want)
+			}
+		}
+	}
+
+	if got := Class(net.ParseIP("2001:db8::1")); got != "n/a (classful addressing is IPv4-only)" {
+		t.Errorf("Class(2001:db8::1) = %q, want the IPv4-only note", got)
+	}
+}
@@ -0,0 +1,12 @@
+package ipcalc
+
+import "errors"
+
+// Errors returned when parsing networks and masks. They are wrapped with the
+// offending input, so callers should test for them with errors.Is.
+var (
+	ErrInvalidCIDR       = errors.New("invalid CIDR notation")
+	ErrNonContiguousMask = errors.New("non-contiguous mask")
+	ErrPrefixOutOfRange  = errors.New("prefix length out of range")
+	ErrFamilyMismatch    = errors.New("address family mismatch")
+)
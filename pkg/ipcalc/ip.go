@@ -0,0 +1,157 @@
+package ipcalc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"net"
+	"strings"
+)
+
+// NextIP returns the address following ip, treating it as a big-endian
+// integer. The address wraps around to zero after the last address.
+func NextIP(ip net.IP) net.IP {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			break
+		}
+	}
+	return next
+}
+
+// IPToInt returns the big-endian integer value of ip. IPv4 addresses are
+// converted from their 4-byte form, so the value fits in 32 bits.
+func IPToInt(ip net.IP) *big.Int {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return new(big.Int).SetBytes(ip)
+}
+
+// addressInt returns the integer value of ip as an address of size bits. An
+// IPv4-mapped address in a 128-bit network keeps its ::ffff: prefix, which
+// IPToInt would drop.
+func addressInt(ip net.IP, size int) *big.Int {
+	if size == 8*net.IPv6len {
+		return new(big.Int).SetBytes(ip.To16())
+	}
+	return IPToInt(ip)
+}
+
+// IPToUint32 returns the integer value of an IPv4 address, or 0 if ip is
+// not an IPv4 address.
+func IPToUint32(ip net.IP) uint32 {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint32(ip4)
+}
+
+// Uint32ToIP returns the IPv4 address with integer value n.
+func Uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
+
+// IPToHex returns ip as an upper-case hexadecimal number, such as 0xC0A80101
+// for 192.168.1.1.
+func IPToHex(ip net.IP) string {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	return fmt.Sprintf("0x%X", []byte(ip))
+}
+
+// ExpandIPv6 returns an IPv6 address with all eight hextets written out in
+// four zero-padded digits, such as
+// 2001:0db8:0000:0000:0000:0000:0000:0001 for 2001:db8::1. An IPv4 address
+// in its 16-byte form is expanded as the IPv4-mapped address it is, and one
+// in its 4-byte form gives an empty string.
+func ExpandIPv6(ip net.IP) string {
+	if len(ip) != net.IPv6len {
+		return ""
+	}
+
+	hextets := make([]string, 0, net.IPv6len/2)
+	for i := 0; i < net.IPv6len; i += 2 {
+		hextets = append(hextets, fmt.Sprintf("%02x%02x", ip[i], ip[i+1]))
+	}
+	return strings.Join(hextets, ":")
+}
+
+// intToIP converts n back into an IP address of the given length in bytes.
+func intToIP(n *big.Int, length int) net.IP {
+	ip := make(net.IP, length)
+	n.FillBytes(ip)
+	return ip
+}
+
+// To6to4 returns the 6to4 /48 prefix (RFC 3056) of an IPv4 address, which
+// places the 32 bits of the address right after 2002::/16, as in
+// 2002:c000:201:: for 192.0.2.1. It returns nil if ip is not an IPv4
+// address.
+func To6to4(ip net.IP) net.IP {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil
+	}
+
+	prefix := make(net.IP, net.IPv6len)
+	prefix[0], prefix[1] = 0x20, 0x02
+	copy(prefix[2:], ip4)
+	return prefix
+}
+
+// ToMapped returns the IPv4-mapped IPv6 address (::ffff:192.0.2.1) of an
+// IPv4 address, or nil if ip is not an IPv4 address.
+func ToMapped(ip net.IP) net.IP {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return nil
+	}
+	return ip4.To16()
+}
+
+// EUI64 returns the modified EUI-64 interface identifier (RFC 4291 appendix
+// A) of a 48-bit MAC address: ff:fe is inserted between its two halves and
+// the universal/local bit is flipped, so 00:1a:2b:3c:4d:5e gives
+// 021a:2bff:fe3c:4d5e.
+func EUI64(mac net.HardwareAddr) ([]byte, error) {
+	if len(mac) != 6 {
+		return nil, fmt.Errorf("%s is not a 48-bit MAC address", mac)
+	}
+
+	id := []byte{mac[0] ^ 0x02, mac[1], mac[2], 0xff, 0xfe, mac[3], mac[4], mac[5]}
+	return id, nil
+}
+
+// EUI64Address returns the address of an interface with the given MAC
+// address in an IPv6 network of /64 or shorter, made of the first 64 bits of
+// the network and the modified EUI-64 interface identifier of the MAC.
+func EUI64Address(network *net.IPNet, mac net.HardwareAddr) (net.IP, error) {
+	ones, size := network.Mask.Size()
+	if size != 8*net.IPv6len || network.IP.To4() != nil {
+		return nil, fmt.Errorf("%s is not an IPv6 network", canonicalNet(network))
+	}
+	if ones > 64 {
+		return nil, fmt.Errorf("%s is longer than /64 and has no room for an interface identifier", canonicalNet(network))
+	}
+
+	id, err := EUI64(mac)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, network.IP.Mask(network.Mask)[:8])
+	copy(ip[8:], id)
+	return ip, nil
+}
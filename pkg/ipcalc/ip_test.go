@@ -0,0 +1,23 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestExpandIPv6(t *testing.T) {
+	tests := []struct {
+		ip   net.IP
+		want string
+	}{
+		{net.ParseIP("2001:db8::1"), "2001:0db8:0000:0000:0000:0000:0000:0001"},
+		{net.ParseIP("::ffff:1.2.3.4"), "0000:0000:0000:0000:0000:ffff:0102:0304"},
+		{net.ParseIP("1.2.3.4").To4(), ""},
+	}
+
+	for _, tt := range tests {
+		if got := ExpandIPv6(tt.ip); got != tt.want {
+			t.Errorf("ExpandIPv6(%s) = %q, want %q", tt.ip, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,507 @@
+// Package ipcalc calculates network information for IPv4 and IPv6 CIDRs.
+package ipcalc
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// Info holds the result of a network calculation.
+type Info struct {
+	// Address is the address as given by the user, host bits included.
+	Address net.IP
+	Network net.IP
+	// Broadcast is nil for IPv6 networks, which have no broadcast address.
+	Broadcast net.IP
+	HostMin   net.IP
+	HostMax   net.IP
+	Mask      net.IPMask
+	Wildcard  net.IP
+	Prefix    int
+	// Class and Privacy are only set for IPv4 networks. For IPv4, Scope is
+	// empty unless the network is in a special-use range; IPv6 networks get
+	// their address type from ClassifyIPv6.
+	Class   string
+	Privacy string
+	Scope   string
+	// Hosts is the number of usable host addresses, and Total the number of
+	// addresses in the network including the network and broadcast address.
+	Hosts *big.Int
+	Total *big.Int
+	// Inferred is set when the input had no prefix and the classful default
+	// mask of the address was assumed.
+	Inferred bool
+}
+
+// IsIPv6 reports whether the network is an IPv6 network. An IPv4 network
+// always holds its addresses in their 4-byte form, so an IPv4-mapped network
+// calculated with FamilyIPv6 is an IPv6 one.
+func (i Info) IsIPv6() bool {
+	return len(i.Network) == net.IPv6len
+}
+
+// IPNet returns the network as a *net.IPNet.
+func (i Info) IPNet() *net.IPNet {
+	return &net.IPNet{IP: i.Network, Mask: i.Mask}
+}
+
+// HostBitsSet reports whether the address given by the user has host bits
+// set, making it differ from the network address.
+func (i Info) HostBitsSet() bool {
+	return !i.Address.Equal(i.Network)
+}
+
+// HasHosts reports whether host calculations apply to the network. They do
+// not for class D (multicast) and class E (reserved) IPv4 networks, whose
+// addresses are never assigned to hosts, so their host range and count are
+// only the arithmetic of the mask.
+func (i Info) HasHosts() bool {
+	return i.IsIPv6() || i.Network.To4()[0] < 224
+}
+
+// Contains reports whether the network contains ip.
+func (i Info) Contains(ip net.IP) bool {
+	return i.IPNet().Contains(ip)
+}
+
+// Family selects whether an address is interpreted as IPv4 or IPv6.
+type Family int
+
+const (
+	// FamilyAuto interprets an address in the family it is written in.
+	// IPv4-mapped IPv6 networks such as ::ffff:192.168.1.0/120 are
+	// calculated as the IPv4 network they map.
+	FamilyAuto Family = iota
+	// FamilyIPv4 only accepts IPv4 addresses, including IPv4-mapped ones.
+	FamilyIPv4
+	// FamilyIPv6 only accepts IPv6 addresses. IPv4-mapped networks are
+	// calculated as the IPv6 network they are written as.
+	FamilyIPv6
+)
+
+// ParseFamily parses a family name: auto, v4 or v6.
+func ParseFamily(s string) (Family, error) {
+	switch s {
+	case "auto":
+		return FamilyAuto, nil
+	case "v4":
+		return FamilyIPv4, nil
+	case "v6":
+		return FamilyIPv6, nil
+	}
+	return 0, fmt.Errorf("invalid address family %q: must be auto, v4 or v6", s)
+}
+
+// Calculate parses input and returns the network information for it. The
+// input is either in CIDR notation (192.168.1.0/24), an IPv4 address
+// followed by a dotted-decimal netmask (192.168.1.0 255.255.255.0), an IPv4
+// address followed by a wildcard mask (192.168.1.0 wildcard 0.0.0.255), an
+// address followed by a prefix length (192.168.1.0 24), or a bare IPv4
+// address, which gets the classful default mask of its class.
+func Calculate(input string) (Info, error) {
+	return CalculateFamily(input, FamilyAuto)
+}
+
+// CalculateFamily is like Calculate, but interprets input as an address of
+// the given family and fails if it is not one.
+func CalculateFamily(input string, family Family) (Info, error) {
+	ip, ipNet, err := parse(input, family)
+	if err != nil {
+		return Info{}, err
+	}
+
+	var info Info
+	if family == FamilyIPv6 {
+		info = calculateIPv6(ip, ipNet)
+	} else {
+		info = calculate(ip, ipNet)
+	}
+	info.Inferred = isBareAddress(input)
+	return info, nil
+}
+
+// CalculateNet returns the network information for an already parsed
+// network. Its mask must be valid, as checked by ValidateMask.
+func CalculateNet(ipNet *net.IPNet) Info {
+	return calculate(ipNet.IP, ipNet)
+}
+
+// CalculateNetFamily is like CalculateNet, but calculates a network with a
+// 16-byte mask as IPv6 when family is FamilyIPv6, even if it is
+// IPv4-mapped.
+func CalculateNetFamily(ipNet *net.IPNet, family Family) Info {
+	if family == FamilyIPv6 && len(ipNet.Mask) == net.IPv6len {
+		return calculateIPv6(ipNet.IP, ipNet)
+	}
+	return calculate(ipNet.IP, ipNet)
+}
+
+// ParseNetwork parses input in any of the forms accepted by Calculate and
+// returns the network it describes.
+func ParseNetwork(input string) (*net.IPNet, error) {
+	_, ipNet, err := parse(input, FamilyAuto)
+	return ipNet, err
+}
+
+// ParseMask parses a dotted-decimal IPv4 netmask such as 255.255.255.0. The
+// mask must be contiguous.
+func ParseMask(s string) (net.IPMask, error) {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q is not a netmask", ErrInvalidCIDR, s)
+	}
+
+	mask := net.IPMask(ip)
+	if err := ValidateMask(mask); err != nil {
+		return nil, err
+	}
+	return mask, nil
+}
+
+// NormalizeMask parses an IPv4 mask written in any of the forms the input
+// accepts: a prefix length (24 or /24), a dotted-decimal netmask
+// (255.255.255.0) or a wildcard mask (wildcard 0.0.0.255). All of them give
+// the same 4-byte mask.
+func NormalizeMask(s string) (net.IPMask, error) {
+	fields := strings.Fields(s)
+	switch {
+	case len(fields) == 2 && fields[0] == "wildcard":
+		return ParseWildcard(fields[1])
+	case len(fields) == 1 && isPrefixLength(strings.TrimPrefix(s, "/")):
+		prefix, _ := strconv.Atoi(strings.TrimPrefix(s, "/"))
+		return PrefixToMask(prefix)
+	case len(fields) == 1:
+		return ParseMask(s)
+	}
+	return nil, fmt.Errorf("%w: %q is not a netmask", ErrInvalidCIDR, s)
+}
+
+// MasksEqual reports whether a and b are the same mask. An IPv4 mask in its
+// 16-byte form, as used for IPv4-mapped addresses, equals its 4-byte form.
+func MasksEqual(a, b net.IPMask) bool {
+	_, a = normalizeIPv4(net.IPv4zero, a)
+	_, b = normalizeIPv4(net.IPv4zero, b)
+	return bytes.Equal(a, b)
+}
+
+// ValidateMask checks that mask is a 4-byte IPv4 or 16-byte IPv6 mask whose
+// one bits are contiguous, like 255.255.255.0 and unlike 255.0.255.0. A
+// non-contiguous mask has no prefix length: mask.Size reports it as 0 bits,
+// which would otherwise be taken for a /0.
+func ValidateMask(mask net.IPMask) error {
+	if len(mask) != net.IPv4len && len(mask) != net.IPv6len {
+		return fmt.Errorf("%w: mask of %d bytes is neither IPv4 nor IPv6", ErrNonContiguousMask, len(mask))
+	}
+	if _, bits := mask.Size(); bits == 0 {
+		return fmt.Errorf("%w %s", ErrNonContiguousMask, net.IP(mask))
+	}
+	return nil
+}
+
+// PrefixToMask returns the IPv4 netmask for a prefix length between 0 and 32.
+func PrefixToMask(prefix int) (net.IPMask, error) {
+	if prefix < 0 || prefix > 8*net.IPv4len {
+		return nil, fmt.Errorf("%w: /%d is not between 0 and 32", ErrPrefixOutOfRange, prefix)
+	}
+	return net.CIDRMask(prefix, 8*net.IPv4len), nil
+}
+
+// MaskToPrefix returns the prefix length of a contiguous netmask.
+func MaskToPrefix(mask net.IPMask) (int, error) {
+	if err := ValidateMask(mask); err != nil {
+		return 0, err
+	}
+	return maskSize(mask), nil
+}
+
+// ParseWildcard parses a Cisco ACL style wildcard mask such as 0.0.0.255 and
+// returns the netmask it is the inverse of. The resulting mask must be
+// contiguous.
+func ParseWildcard(s string) (net.IPMask, error) {
+	ip := net.ParseIP(s).To4()
+	if ip == nil {
+		return nil, fmt.Errorf("%w: %q is not a wildcard mask", ErrInvalidCIDR, s)
+	}
+
+	mask := net.IPMask(Wildcard(net.IPMask(ip)))
+	if err := ValidateMask(mask); err != nil {
+		return nil, fmt.Errorf("wildcard mask %q does not invert to a valid netmask: %w", s, err)
+	}
+	return mask, nil
+}
+
+func parse(input string, family Family) (net.IP, *net.IPNet, error) {
+	fields := strings.Fields(input)
+	if len(fields) == 2 && isPrefixLength(fields[1]) {
+		// "192.168.1.0 24" is CIDR notation without the slash.
+		input = fields[0] + "/" + fields[1]
+		fields = fields[:1]
+	}
+
+	switch {
+	case len(fields) > 1 && family == FamilyIPv6:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv6 network, netmasks are IPv4-only", ErrFamilyMismatch, input)
+	case len(fields) == 2:
+		return parseWithMask(fields[0], fields[1], ParseMask)
+	case len(fields) == 3 && fields[1] == "wildcard":
+		return parseWithMask(fields[0], fields[2], ParseWildcard)
+	}
+
+	if isBareAddress(input) {
+		return parseClassful(strings.TrimSpace(input), family)
+	}
+
+	ip, ipNet, err := net.ParseCIDR(input)
+	if err != nil {
+		return nil, nil, cidrError(input)
+	}
+
+	v4 := ipNet.IP.To4() != nil
+	mapped := v4 && len(ipNet.Mask) == net.IPv6len
+	switch {
+	case family == FamilyIPv4 && !v4:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv4 network", ErrFamilyMismatch, input)
+	case family == FamilyIPv6 && v4 && !mapped:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv6 network", ErrFamilyMismatch, input)
+	}
+
+	// An IPv4-mapped network keeps the IPv4 address in its last four bytes,
+	// and its prefix is at least /96 since it covers ::ffff:0:0/96. With
+	// FamilyIPv6 it stays in its 16-byte form.
+	if mapped && family != FamilyIPv6 {
+		ipNet = &net.IPNet{IP: ipNet.IP.To4(), Mask: ipNet.Mask[12:]}
+		ip = ip.To4()
+	}
+	return ip, ipNet, nil
+}
+
+// cidrError returns the error for input that net.ParseCIDR rejected. A valid
+// address with a numeric prefix that is too long for its family gets
+// ErrPrefixOutOfRange rather than ErrInvalidCIDR.
+func cidrError(input string) error {
+	address, prefix, ok := strings.Cut(input, "/")
+	ip := net.ParseIP(address)
+	if !ok || ip == nil || !isPrefixLength(prefix) {
+		return fmt.Errorf("%w %q", ErrInvalidCIDR, input)
+	}
+
+	bits := 8 * net.IPv6len
+	if ip.To4() != nil && !strings.Contains(address, ":") {
+		bits = 8 * net.IPv4len
+	}
+	return fmt.Errorf("%w: /%s in %q is not between 0 and %d", ErrPrefixOutOfRange, prefix, input, bits)
+}
+
+// isPrefixLength reports whether s is a prefix length written without the
+// leading slash.
+func isPrefixLength(s string) bool {
+	_, err := strconv.ParseUint(s, 10, 8)
+	return err == nil
+}
+
+// isBareAddress reports whether input is a single address without a prefix.
+func isBareAddress(input string) bool {
+	return len(strings.Fields(input)) == 1 && !strings.Contains(input, "/")
+}
+
+// parseClassful parses an IPv4 address and gives it the classful default
+// mask of its class. An IPv4-mapped address is only treated as IPv4 when
+// family asks for it, since it is written as an IPv6 address.
+func parseClassful(address string, family Family) (net.IP, *net.IPNet, error) {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return nil, nil, fmt.Errorf("%w %q", ErrInvalidCIDR, address)
+	}
+
+	v6 := ip.To4() == nil || strings.Contains(address, ":") && family != FamilyIPv4
+	switch {
+	case v6 && family == FamilyIPv4:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv4 address", ErrFamilyMismatch, address)
+	case !v6 && family == FamilyIPv6:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv6 address", ErrFamilyMismatch, address)
+	case v6:
+		return nil, nil, fmt.Errorf("%w: IPv6 address %q needs a prefix length", ErrInvalidCIDR, address)
+	}
+
+	prefix, err := ClassfulPrefix(ip)
+	if err != nil {
+		return nil, nil, err
+	}
+	mask := net.CIDRMask(prefix, 8*net.IPv4len)
+	return ip.To4(), &net.IPNet{IP: ip.To4().Mask(mask), Mask: mask}, nil
+}
+
+// parseWithMask parses an IPv4 address and a mask written in the form
+// understood by parseMask.
+func parseWithMask(address, mask string, parseMask func(string) (net.IPMask, error)) (net.IP, *net.IPNet, error) {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return nil, nil, fmt.Errorf("%w %q", ErrInvalidCIDR, address)
+	case ip.To4() == nil:
+		return nil, nil, fmt.Errorf("%w: %q is not an IPv4 address, netmasks are IPv4-only", ErrFamilyMismatch, address)
+	}
+	ip = ip.To4()
+
+	m, err := parseMask(mask)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ip, &net.IPNet{IP: ip.Mask(m), Mask: m}, nil
+}
+
+func calculate(ip net.IP, ipNet *net.IPNet) Info {
+	if ipNet.IP.To4() == nil {
+		return calculateIPv6(ip, ipNet)
+	}
+
+	// A network built by hand may hold an IPv4 address in its 16-byte form,
+	// so report the address and mask in their 4-byte form.
+	_, mask := normalizeIPv4(ipNet.IP, ipNet.Mask)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+
+	info := Info{
+		Address: ip,
+		Mask:    mask,
+		Prefix:  maskSize(mask),
+		Total:   TotalAddresses(mask),
+	}
+
+	info.Network, info.Broadcast, info.HostMin, info.HostMax = calculateNetworkInfo(ipNet.IP, mask)
+	info.Wildcard = Wildcard(mask)
+	info.Class = Class(ipNet.IP)
+	info.Privacy = NetworkPrivacy(ipNet)
+	info.Scope = ClassifyScope(ipNet.IP)
+	info.Hosts = UsableHosts(mask)
+	if info.Prefix == 0 {
+		// The default route spans every class, and is not the "this
+		// network" block its address happens to fall in.
+		info.Class = "All classes"
+		info.Scope = "Default Route"
+	}
+	return info
+}
+
+// calculateIPv6 calculates ipNet as an IPv6 network, even when it is an
+// IPv4-mapped one.
+func calculateIPv6(ip net.IP, ipNet *net.IPNet) Info {
+	info := Info{
+		Address: ip.To16(),
+		Mask:    ipNet.Mask,
+		Prefix:  maskSize(ipNet.Mask),
+		Total:   TotalAddresses(ipNet.Mask),
+		Hosts:   UsableHosts(ipNet.Mask),
+		Scope:   ClassifyIPv6(ipNet.IP),
+	}
+	info.Network, info.HostMin, info.HostMax = calculateIPv6NetworkInfo(ipNet.IP.To16(), ipNet.Mask)
+	if info.Prefix == 0 {
+		info.Scope = "Default Route"
+	}
+	return info
+}
+
+// Calculate the network, broadcast, and range of host IP addresses
+func calculateNetworkInfo(ip net.IP, mask net.IPMask) (net.IP, net.IP, net.IP, net.IP) {
+	ip, mask = normalizeIPv4(ip, mask)
+
+	// Every address below is a fresh copy, so updating one never changes
+	// another regardless of the order they are derived in.
+	network := ip.Mask(mask)
+	broadcast := make(net.IP, len(network))
+	copy(broadcast, network)
+	for i := range broadcast {
+		broadcast[i] |= ^mask[i]
+	}
+
+	hostMin := make(net.IP, len(network))
+	copy(hostMin, network)
+
+	hostMax := make(net.IP, len(broadcast))
+	copy(hostMax, broadcast)
+
+	// A /31 is a point-to-point link (RFC 3021) where both addresses are
+	// hosts, and a /32 is a single host route, so there is nothing to
+	// exclude from the range.
+	if ones, bits := mask.Size(); bits-ones > 1 {
+		hostMin[len(hostMin)-1]++
+		hostMax[len(hostMax)-1]--
+	}
+
+	return network, broadcast, hostMin, hostMax
+}
+
+// normalizeIPv4 brings an IPv4 address and its mask to the same 4-byte
+// length, so that they can be combined byte by byte. The address may be in
+// its 16-byte form and the mask may be an IPv6 mask covering the
+// IPv4-mapped prefix.
+func normalizeIPv4(ip net.IP, mask net.IPMask) (net.IP, net.IPMask) {
+	ip4 := ip.To4()
+	if ip4 == nil {
+		return ip, mask
+	}
+
+	if len(mask) == net.IPv6len {
+		if ones, _ := mask.Size(); ones < 96 {
+			return ip, mask
+		}
+		mask = mask[12:]
+	}
+	return ip4, mask
+}
+
+// Calculate the network, first and last address of an IPv6 network.
+// IPv6 has no broadcast address, so every address in the prefix is usable.
+func calculateIPv6NetworkInfo(ip net.IP, mask net.IPMask) (net.IP, net.IP, net.IP) {
+	network := ip.Mask(mask)
+	first := make(net.IP, len(network))
+	copy(first, network)
+
+	last := make(net.IP, len(network))
+	copy(last, network)
+	for i := range last {
+		last[i] |= ^mask[i]
+	}
+
+	return network, first, last
+}
+
+// Helper functions
+
+func maskSize(mask net.IPMask) int {
+	ones, _ := mask.Size()
+	return ones
+}
+
+// Wildcard returns the inverse of mask, as used by Cisco ACLs.
+func Wildcard(mask net.IPMask) net.IP {
+	wildcard := make(net.IP, len(mask))
+	for i := range mask {
+		wildcard[i] = ^mask[i]
+	}
+	return wildcard
+}
+
+// UsableHosts returns the number of usable host addresses in a network with
+// the given mask. IPv4 networks lose the network and broadcast address,
+// except for a /31, which has two usable hosts (RFC 3021), and a /32, which
+// has one. Every address of an IPv6 network is usable.
+func UsableHosts(mask net.IPMask) *big.Int {
+	total := TotalAddresses(mask)
+	if ones, bits := mask.Size(); bits == 8*net.IPv6len || bits-ones <= 1 {
+		return total
+	}
+	return total.Sub(total, big.NewInt(2))
+}
+
+// TotalAddresses returns the number of addresses in a network with the given
+// mask, including the network and broadcast address.
+func TotalAddresses(mask net.IPMask) *big.Int {
+	ones, bits := mask.Size()
+	return new(big.Int).Lsh(big.NewInt(1), uint(bits-ones))
+}
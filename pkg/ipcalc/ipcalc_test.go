@@ -0,0 +1,234 @@
+package ipcalc
+
+import (
+	"errors"
+	"math/big"
+	"net"
+	"testing"
+)
+
+func TestCalculateNetMixedLengths(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+		mask net.IPMask
+	}{
+		{"16-byte IP, 4-byte mask", net.ParseIP("192.168.1.77"), net.CIDRMask(24, 32)},
+		{"4-byte IP, 16-byte mask", net.ParseIP("192.168.1.77").To4(), net.CIDRMask(120, 128)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := CalculateNet(&net.IPNet{IP: tt.ip, Mask: tt.mask})
+
+			if got := info.IPNet().String(); got != "192.168.1.0/24" {
+				t.Errorf("network = %s, want 192.168.1.0/24", got)
+			}
+			if got := info.Broadcast.String(); got != "192.168.1.255" {
+				t.Errorf("broadcast = %s, want 192.168.1.255", got)
+			}
+			if got := info.HostMin.String(); got != "192.168.1.1" {
+				t.Errorf("HostMin = %s, want 192.168.1.1", got)
+			}
+			if got := info.HostMax.String(); got != "192.168.1.254" {
+				t.Errorf("HostMax = %s, want 192.168.1.254", got)
+			}
+			if info.Prefix != 24 || len(info.Mask) != net.IPv4len {
+				t.Errorf("mask = %v /%d, want a 4-byte /24", info.Mask, info.Prefix)
+			}
+		})
+	}
+}
+
+func TestCalculateNetPrefixes(t *testing.T) {
+	tests := []struct {
+		cidr                                 string
+		network, broadcast, hostMin, hostMax string
+		hosts                                string
+	}{
+		{"0.0.0.0/0", "0.0.0.0", "255.255.255.255", "0.0.0.1", "255.255.255.254", "4294967294"},
+		{"192.168.1.77/24", "192.168.1.0", "192.168.1.255", "192.168.1.1", "192.168.1.254", "254"},
+		{"10.0.0.5/30", "10.0.0.4", "10.0.0.7", "10.0.0.5", "10.0.0.6", "2"},
+		// Both addresses of a /31 are hosts, and a /32 is its only host.
+		{"10.0.0.5/31", "10.0.0.4", "10.0.0.5", "10.0.0.4", "10.0.0.5", "2"},
+		{"10.0.0.5/32", "10.0.0.5", "10.0.0.5", "10.0.0.5", "10.0.0.5", "1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cidr, func(t *testing.T) {
+			_, ipNet, err := net.ParseCIDR(tt.cidr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := CalculateNet(ipNet)
+
+			for _, f := range []struct {
+				name string
+				got  net.IP
+				want string
+			}{
+				{"network", info.Network, tt.network},
+				{"broadcast", info.Broadcast, tt.broadcast},
+				{"HostMin", info.HostMin, tt.hostMin},
+				{"HostMax", info.HostMax, tt.hostMax},
+			} {
+				if f.got.String() != f.want {
+					t.Errorf("%s = %s, want %s", f.name, f.got, f.want)
+				}
+			}
+			if got := info.Hosts.String(); got != tt.hosts {
+				t.Errorf("hosts = %s, want %s", got, tt.hosts)
+			}
+		})
+	}
+}
+
+func TestCalculateNetworkInfo16ByteIPv4(t *testing.T) {
+	ip := make(net.IP, net.IPv6len)
+	copy(ip, []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xff, 0xff, 10, 1, 2, 3})
+
+	network, broadcast, first, last := calculateNetworkInfo(ip, net.CIDRMask(8, 32))
+	for _, tt := range []struct {
+		name string
+		got  net.IP
+		want string
+	}{
+		{"network", network, "10.0.0.0"},
+		{"broadcast", broadcast, "10.255.255.255"},
+		{"first", first, "10.0.0.1"},
+		{"last", last, "10.255.255.254"},
+	} {
+		if tt.got.String() != tt.want || len(tt.got) != net.IPv4len {
+			t.Errorf("%s = %v (%d bytes), want %s in 4 bytes", tt.name, tt.got, len(tt.got), tt.want)
+		}
+	}
+}
+
+func TestUsableHosts(t *testing.T) {
+	tests := []struct {
+		mask net.IPMask
+		want string
+	}{
+		{net.CIDRMask(0, 32), "4294967294"},
+		{net.CIDRMask(8, 32), "16777214"},
+		{net.CIDRMask(64, 128), new(big.Int).Lsh(big.NewInt(1), 64).String()},
+	}
+
+	for _, tt := range tests {
+		ones, bits := tt.mask.Size()
+		if got := UsableHosts(tt.mask).String(); got != tt.want {
+			t.Errorf("UsableHosts(/%d of %d bits) = %s, want %s", ones, bits, got, tt.want)
+		}
+	}
+}
+
+func TestValidateMask(t *testing.T) {
+	tests := []struct {
+		name string
+		mask net.IPMask
+	}{
+		{"255.0.255.0", net.IPv4Mask(255, 0, 255, 0)},
+		{"255.255.255.1", net.IPv4Mask(255, 255, 255, 1)},
+		{"0.255.255.255", net.IPv4Mask(0, 255, 255, 255)},
+		{"5 bytes", net.IPMask{255, 255, 255, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		if err := ValidateMask(tt.mask); err == nil {
+			t.Errorf("ValidateMask(%s) succeeded, want an error", tt.name)
+		}
+	}
+
+	for _, mask := range []net.IPMask{net.CIDRMask(0, 32), net.CIDRMask(24, 32), net.CIDRMask(64, 128)} {
+		if err := ValidateMask(mask); err != nil {
+			t.Errorf("ValidateMask(%v) = %v, want nil", mask, err)
+		}
+	}
+}
+
+func TestCalculateDefaultRoute(t *testing.T) {
+	info, err := Calculate("0.0.0.0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := info.Network.String(); got != "0.0.0.0" {
+		t.Errorf("network = %s, want 0.0.0.0", got)
+	}
+	if got := info.Broadcast.String(); got != "255.255.255.255" {
+		t.Errorf("broadcast = %s, want 255.255.255.255", got)
+	}
+	if got := info.Hosts.String(); got != "4294967294" {
+		t.Errorf("hosts = %s, want 4294967294", got)
+	}
+	if info.Class != "All classes" || info.Scope != "Default Route" {
+		t.Errorf("class = %q, scope = %q, want All classes and Default Route", info.Class, info.Scope)
+	}
+}
+
+func TestNormalizeMaskForms(t *testing.T) {
+	want := net.CIDRMask(24, 32)
+	for _, form := range []string{"255.255.255.0", "/24", "24", "wildcard 0.0.0.255"} {
+		mask, err := NormalizeMask(form)
+		if err != nil {
+			t.Errorf("NormalizeMask(%q) failed: %v", form, err)
+			continue
+		}
+		if !MasksEqual(mask, want) {
+			t.Errorf("NormalizeMask(%q) = %v, want %v", form, mask, want)
+		}
+	}
+
+	if !MasksEqual(net.CIDRMask(120, 128), want) {
+		t.Error("MasksEqual(/120 of 128 bits, /24) = false, want true")
+	}
+}
+
+func TestCalculateFamilyMapped(t *testing.T) {
+	v4, err := CalculateFamily("::ffff:192.168.1.0/120", FamilyAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v4.IsIPv6() || v4.Prefix != 24 || v4.Broadcast.String() != "192.168.1.255" {
+		t.Errorf("auto: got %s with broadcast %s, want the IPv4 network 192.168.1.0/24", v4.IPNet(), v4.Broadcast)
+	}
+
+	v6, err := CalculateFamily("::ffff:192.168.1.0/120", FamilyIPv6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v6.IsIPv6() || v6.Prefix != 120 || v6.Broadcast != nil {
+		t.Errorf("v6: got prefix %d, IPv6 %v, broadcast %s, want the IPv6 network /120", v6.Prefix, v6.IsIPv6(), v6.Broadcast)
+	}
+	if v6.Hosts.Int64() != 256 || v6.Scope != "IPv4-Mapped" {
+		t.Errorf("v6: got %s hosts in %q, want 256 in \"IPv4-Mapped\"", v6.Hosts, v6.Scope)
+	}
+}
+
+func TestParseErrorsAreTyped(t *testing.T) {
+	tests := []struct {
+		input string
+		want  error
+	}{
+		{"10.0.0.0 1.2.3", ErrInvalidCIDR},
+		{"10.0.0.0 255.0.255.0", ErrNonContiguousMask},
+		{"10.0.0.0 wildcard zz", ErrInvalidCIDR},
+		{"x 255.0.0.0", ErrInvalidCIDR},
+		{"2001:db8::1 255.255.255.0", ErrFamilyMismatch},
+		{"2001:db8::1", ErrInvalidCIDR},
+		{"224.0.0.1", ErrInvalidCIDR},
+	}
+
+	for _, tt := range tests {
+		if _, err := Calculate(tt.input); !errors.Is(err, tt.want) {
+			t.Errorf("Calculate(%q) error = %v, want %v", tt.input, err, tt.want)
+		}
+	}
+
+	if err := ValidateMask(net.IPMask{255, 255, 255, 0, 0}); !errors.Is(err, ErrNonContiguousMask) {
+		t.Errorf("ValidateMask of 5 bytes error = %v, want %v", err, ErrNonContiguousMask)
+	}
+	if _, err := NormalizeMask("255.255.255.0 24"); !errors.Is(err, ErrInvalidCIDR) {
+		t.Errorf("NormalizeMask error = %v, want %v", err, ErrInvalidCIDR)
+	}
+}
@@ -0,0 +1,123 @@
+package ipcalc
+
+import "net"
+
+// Relation describes how two networks relate to each other. Two CIDR blocks
+// either nest or do not overlap at all, so there is no partial overlap.
+type Relation int
+
+const (
+	Disjoint Relation = iota
+	AContainsB
+	BContainsA
+	Equal
+)
+
+func (r Relation) String() string {
+	switch r {
+	case AContainsB:
+		return "AContainsB"
+	case BContainsA:
+		return "BContainsA"
+	case Equal:
+		return "Equal"
+	default:
+		return "Disjoint"
+	}
+}
+
+// Overlaps returns the relation between networks a and b.
+func Overlaps(a, b *net.IPNet) Relation {
+	a, b = canonicalNet(a), canonicalNet(b)
+	aContainsB, bContainsA := containsNet(a, b), containsNet(b, a)
+
+	switch {
+	case aContainsB && bContainsA:
+		return Equal
+	case aContainsB:
+		return AContainsB
+	case bContainsA:
+		return BContainsA
+	default:
+		return Disjoint
+	}
+}
+
+// SameNetwork reports whether a and b are in the same network under mask,
+// that is whether they are equal once their host bits are cleared.
+func SameNetwork(a, b net.IP, mask net.IPMask) bool {
+	if len(mask) == net.IPv4len {
+		a, b = a.To4(), b.To4()
+	}
+	na, nb := a.Mask(mask), b.Mask(mask)
+	return na != nil && na.Equal(nb)
+}
+
+// LongestMatch returns the most specific of networks that contains ip, as a
+// router picks the route for an address, and false if none contains it.
+func LongestMatch(networks []*net.IPNet, ip net.IP) (*net.IPNet, bool) {
+	var best *net.IPNet
+	bestOnes := -1
+	for _, network := range networks {
+		if ones, _ := network.Mask.Size(); ones > bestOnes && network.Contains(ip) {
+			best, bestOnes = canonicalNet(network), ones
+		}
+	}
+	return best, best != nil
+}
+
+// NetworkChange is a network of an address plan that was replaced by an
+// overlapping one, such as a /24 grown into a /23.
+type NetworkChange struct {
+	Before, After *net.IPNet
+}
+
+// DiffNetworks compares two lists of networks, such as two versions of an
+// address plan, and returns the networks only in before, those only in
+// after, and the ones that changed. A network only in before that overlaps
+// one only in after, as when it was resized, is reported as changed. Each
+// network takes part in at most one change, so when two /24s are merged
+// into a /23 the second /24 is reported as removed.
+func DiffNetworks(before, after []*net.IPNet) (removed, added []*net.IPNet, changed []NetworkChange) {
+	gone, arrived := missingFrom(before, after), missingFrom(after, before)
+
+	paired := make([]bool, len(arrived))
+	for _, old := range gone {
+		match := -1
+		for i, network := range arrived {
+			if !paired[i] && Overlaps(old, network) != Disjoint {
+				match = i
+				break
+			}
+		}
+		if match < 0 {
+			removed = append(removed, old)
+			continue
+		}
+		paired[match] = true
+		changed = append(changed, NetworkChange{old, arrived[match]})
+	}
+
+	for i, network := range arrived {
+		if !paired[i] {
+			added = append(added, network)
+		}
+	}
+	return removed, added, changed
+}
+
+// missingFrom returns the networks of a that are not in b.
+func missingFrom(a, b []*net.IPNet) []*net.IPNet {
+	in := make(map[string]bool, len(b))
+	for _, network := range b {
+		in[canonicalNet(network).String()] = true
+	}
+
+	var missing []*net.IPNet
+	for _, network := range a {
+		if network = canonicalNet(network); !in[network.String()] {
+			missing = append(missing, network)
+		}
+	}
+	return missing
+}
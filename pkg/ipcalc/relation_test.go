@@ -0,0 +1,31 @@
+package ipcalc
+
+import (
+	"fmt"
+	"net"
+	"testing"
+)
+
+func TestDiffNetworks(t *testing.T) {
+	parse := func(cidrs ...string) []*net.IPNet {
+		networks := make([]*net.IPNet, 0, len(cidrs))
+		for _, cidr := range cidrs {
+			networks = append(networks, parseCIDR(cidr))
+		}
+		return networks
+	}
+
+	before := parse("10.0.0.0/24", "10.0.2.0/24", "10.0.3.0/24", "10.0.5.0/24")
+	after := parse("10.0.0.0/24", "10.0.2.0/23", "10.0.9.0/24")
+	removed, added, changed := DiffNetworks(before, after)
+
+	if len(changed) != 1 || changed[0].Before.String() != "10.0.2.0/24" || changed[0].After.String() != "10.0.2.0/23" {
+		t.Errorf("changed = %v, want 10.0.2.0/24 -> 10.0.2.0/23", changed)
+	}
+	if got := fmt.Sprint(removed); got != "[10.0.3.0/24 10.0.5.0/24]" {
+		t.Errorf("removed = %s, want 10.0.3.0/24 10.0.5.0/24", got)
+	}
+	if got := fmt.Sprint(added); got != "[10.0.9.0/24]" {
+		t.Errorf("added = %s, want 10.0.9.0/24", got)
+	}
+}
@@ -0,0 +1,67 @@
+package ipcalc
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IsOctetAligned reports whether a prefix length ends on an octet boundary,
+// as /8, /16, /24 and /32 do. Such a network is a single reverse DNS zone,
+// and its wildcard mask is made of whole 0 and 255 octets.
+func IsOctetAligned(prefix int) bool {
+	return prefix%8 == 0
+}
+
+// ReverseDNSZones returns the reverse DNS zones covering network. IPv4
+// prefixes that end between octet boundaries up to /24 are covered by
+// several zones at the next boundary, and prefixes longer than /24 use the
+// RFC 2317 classless delegation form (0/26.1.168.192.in-addr.arpa). IPv6
+// prefixes are rounded to nibble boundaries under ip6.arpa.
+func ReverseDNSZones(network *net.IPNet) []string {
+	ones, size := network.Mask.Size()
+	if size == 8*net.IPv6len {
+		return reverseIPv6Zones(network, ones)
+	}
+
+	ip := network.IP.Mask(network.Mask).To4()
+	if ones > 24 && ones < 32 {
+		return []string{fmt.Sprintf("%d/%d.%d.%d.%d.in-addr.arpa", ip[3], ones, ip[2], ip[1], ip[0])}
+	}
+
+	zonePrefix := (ones + 7) / 8 * 8
+	subnets := []*net.IPNet{network}
+	if zonePrefix != ones {
+		subnets, _ = EnumerateSubnets(network, zonePrefix)
+	}
+
+	zones := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		ip := subnet.IP.To4()
+		labels := make([]string, 0, 5)
+		for i := zonePrefix/8 - 1; i >= 0; i-- {
+			labels = append(labels, fmt.Sprint(ip[i]))
+		}
+		zones = append(zones, strings.Join(append(labels, "in-addr.arpa"), "."))
+	}
+	return zones
+}
+
+func reverseIPv6Zones(network *net.IPNet, ones int) []string {
+	zonePrefix := (ones + 3) / 4 * 4
+	subnets := []*net.IPNet{network}
+	if zonePrefix != ones {
+		subnets, _ = EnumerateSubnets(network, zonePrefix)
+	}
+
+	zones := make([]string, 0, len(subnets))
+	for _, subnet := range subnets {
+		nibbles := fmt.Sprintf("%x", []byte(subnet.IP.To16()))[:zonePrefix/4]
+		labels := make([]string, 0, len(nibbles)+1)
+		for i := len(nibbles) - 1; i >= 0; i-- {
+			labels = append(labels, nibbles[i:i+1])
+		}
+		zones = append(zones, strings.Join(append(labels, "ip6.arpa"), "."))
+	}
+	return zones
+}
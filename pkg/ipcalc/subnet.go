@@ -0,0 +1,224 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"net"
+)
+
+// maxSubnetBits limits how many bits EnumerateSubnets may borrow, so that a
+// request like a /0 into /64s does not try to allocate every subnet. Larger
+// networks can be walked with a SubnetIterator instead.
+const maxSubnetBits = 16
+
+// Split divides network into n equally sized subnets. n must be a power of
+// two, and the network must have enough host bits left to borrow from.
+func Split(network *net.IPNet, n int) ([]*net.IPNet, error) {
+	newPrefix, err := SplitPrefix(network, n)
+	if err != nil {
+		return nil, err
+	}
+	return EnumerateSubnets(network, newPrefix)
+}
+
+// SplitPrefix returns the prefix length of the subnets Split divides network
+// into, without building them.
+func SplitPrefix(network *net.IPNet, n int) (int, error) {
+	if n < 1 || n&(n-1) != 0 {
+		return 0, fmt.Errorf("cannot split into %d subnets: count must be a power of two", n)
+	}
+
+	ones, size := network.Mask.Size()
+	newPrefix := ones + bits.TrailingZeros(uint(n))
+	if newPrefix > size {
+		return 0, fmt.Errorf("cannot split /%d into %d subnets: only %d host bits available", ones, n, size-ones)
+	}
+	return newPrefix, nil
+}
+
+// PrefixForSubnets returns the shortest prefix length that divides network
+// into at least n subnets, borrowing as few host bits as possible: a /24
+// needs three bits, and so /27 subnets, for 6 subnets.
+func PrefixForSubnets(network *net.IPNet, n int) (int, error) {
+	if n < 1 {
+		return 0, fmt.Errorf("invalid subnet count %d", n)
+	}
+
+	ones, size := network.Mask.Size()
+	newPrefix := ones + bits.Len(uint(n-1))
+	if newPrefix > size {
+		return 0, fmt.Errorf("cannot divide /%d into %d subnets: only %d host bits available", ones, n, size-ones)
+	}
+	return newPrefix, nil
+}
+
+// Halves divides network into its two child networks, one bit longer. It is
+// the inverse of aggregating two siblings, and fails for a single address.
+func Halves(network *net.IPNet) (*net.IPNet, *net.IPNet, error) {
+	ones, size := network.Mask.Size()
+	if ones == size {
+		return nil, nil, fmt.Errorf("cannot split %s: a /%d has no host bits", canonicalNet(network), ones)
+	}
+
+	subnets, err := EnumerateSubnets(network, ones+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return subnets[0], subnets[1], nil
+}
+
+// Supernet returns the network with the shorter prefix length newPrefix that
+// contains network.
+func Supernet(network *net.IPNet, newPrefix int) (*net.IPNet, error) {
+	ones, size := network.Mask.Size()
+	if newPrefix < 0 || newPrefix >= ones {
+		return nil, fmt.Errorf("cannot find the /%d supernet of a /%d: the prefix must be shorter than /%d", newPrefix, ones, ones)
+	}
+
+	mask := net.CIDRMask(newPrefix, size)
+	return canonicalNet(&net.IPNet{IP: network.IP.Mask(mask), Mask: mask}), nil
+}
+
+// Locate returns the /newPrefix subnet of parent that contains ip, as found
+// when parent is divided into subnets of that size.
+func Locate(parent *net.IPNet, ip net.IP, newPrefix int) (*net.IPNet, error) {
+	ones, size := parent.Mask.Size()
+	if newPrefix < ones || newPrefix > size {
+		return nil, fmt.Errorf("cannot locate a /%d subnet in a /%d: the prefix must be between /%d and /%d", newPrefix, ones, ones, size)
+	}
+	if !parent.Contains(ip) {
+		return nil, fmt.Errorf("%s is not in %s", ip, canonicalNet(parent))
+	}
+
+	mask := net.CIDRMask(newPrefix, size)
+	return canonicalNet(&net.IPNet{IP: ip.Mask(mask), Mask: mask}), nil
+}
+
+// SubnetCount returns the number of /child subnets in a /parent network.
+// child must be longer than parent, and the count must fit in 64 bits.
+func SubnetCount(parent, child int) (uint64, error) {
+	if child == parent {
+		return 0, fmt.Errorf("cannot count /%d subnets of a /%d: the subnet prefix must be longer than /%d", child, parent, parent)
+	}
+	count, err := SubnetCountBig(parent, child)
+	if err != nil {
+		return 0, err
+	}
+	if !count.IsUint64() {
+		return 0, fmt.Errorf("too many /%d subnets in a /%d to count", child, parent)
+	}
+	return count.Uint64(), nil
+}
+
+// SubnetCountBig is like SubnetCount, but returns the count as a big.Int so
+// that it does not overflow for IPv6, where a /0 holds 2^64 /64 subnets.
+// A /child is also counted as one subnet of a /parent of the same length.
+func SubnetCountBig(parent, child int) (*big.Int, error) {
+	if child < parent {
+		return nil, fmt.Errorf("cannot count /%d subnets of a /%d: the subnet prefix must not be shorter than /%d", child, parent, parent)
+	}
+	return new(big.Int).Lsh(big.NewInt(1), uint(child-parent)), nil
+}
+
+// EnumerateSubnets returns every subnet of network with the given prefix
+// length. newPrefix must be longer than the prefix of network.
+func EnumerateSubnets(network *net.IPNet, newPrefix int) ([]*net.IPNet, error) {
+	it, err := NewSubnetIterator(network, newPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	ones, _ := network.Mask.Size()
+	if newPrefix-ones > maxSubnetBits {
+		return nil, fmt.Errorf("cannot divide /%d into /%d subnets: more than %d subnets", ones, newPrefix, 1<<maxSubnetBits)
+	}
+
+	subnets := make([]*net.IPNet, 0, 1<<(newPrefix-ones))
+	for subnet, ok := it.Next(); ok; subnet, ok = it.Next() {
+		subnets = append(subnets, subnet)
+	}
+	return subnets, nil
+}
+
+// SubnetIterator walks the subnets of a network one at a time, without
+// holding all of them in memory.
+type SubnetIterator struct {
+	next *big.Int
+	end  *big.Int
+	step *big.Int
+	mask net.IPMask
+	size int
+}
+
+// NewSubnetIterator returns an iterator over every subnet of network with
+// the given prefix length, in ascending order. newPrefix must be longer than
+// the prefix of network.
+func NewSubnetIterator(network *net.IPNet, newPrefix int) (*SubnetIterator, error) {
+	ones, size := network.Mask.Size()
+	if newPrefix <= ones {
+		return nil, fmt.Errorf("cannot divide /%d into /%d subnets: the new prefix must be longer than /%d", ones, newPrefix, ones)
+	}
+	if newPrefix > size {
+		return nil, fmt.Errorf("cannot divide /%d into /%d subnets: the prefix must be at most /%d", ones, newPrefix, size)
+	}
+
+	base := addressInt(network.IP.Mask(network.Mask), size)
+	block := new(big.Int).Lsh(big.NewInt(1), uint(size-ones))
+	return &SubnetIterator{
+		next: base,
+		end:  block.Add(base, block),
+		step: new(big.Int).Lsh(big.NewInt(1), uint(size-newPrefix)),
+		mask: net.CIDRMask(newPrefix, size),
+		size: size,
+	}, nil
+}
+
+// Next returns the next subnet, or false once every subnet has been
+// returned.
+func (it *SubnetIterator) Next() (*net.IPNet, bool) {
+	if it.next.Cmp(it.end) >= 0 {
+		return nil, false
+	}
+
+	subnet := &net.IPNet{IP: intToIP(it.next, it.size/8), Mask: it.mask}
+	it.next = new(big.Int).Add(it.next, it.step)
+	return subnet, true
+}
+
+// NextNetwork returns the network of the same size immediately following n.
+// It fails rather than wrapping around past the last address.
+func NextNetwork(n *net.IPNet) (*net.IPNet, error) {
+	ones, size := n.Mask.Size()
+	block := new(big.Int).Lsh(big.NewInt(1), uint(size-ones))
+	next := block.Add(addressInt(n.IP.Mask(n.Mask), size), block)
+	if next.BitLen() > size {
+		return nil, fmt.Errorf("%s is the last /%d network", canonicalNet(n), ones)
+	}
+	return &net.IPNet{IP: intToIP(next, size/8), Mask: n.Mask}, nil
+}
+
+// ShiftNetwork returns the network of the same size k blocks after n, or
+// before it when k is negative. It fails rather than wrapping around the
+// address space.
+func ShiftNetwork(n *net.IPNet, k int64) (*net.IPNet, error) {
+	ones, size := n.Mask.Size()
+	offset := new(big.Int).Lsh(big.NewInt(k), uint(size-ones))
+	shifted := offset.Add(addressInt(n.IP.Mask(n.Mask), size), offset)
+	if shifted.Sign() < 0 || shifted.BitLen() > size {
+		return nil, fmt.Errorf("%s shifted by %d is outside the address space", canonicalNet(n), k)
+	}
+	return &net.IPNet{IP: intToIP(shifted, size/8), Mask: n.Mask}, nil
+}
+
+// PrevNetwork returns the network of the same size immediately preceding n.
+// It fails rather than wrapping around past the first address.
+func PrevNetwork(n *net.IPNet) (*net.IPNet, error) {
+	ones, size := n.Mask.Size()
+	block := new(big.Int).Lsh(big.NewInt(1), uint(size-ones))
+	prev := new(big.Int).Sub(addressInt(n.IP.Mask(n.Mask), size), block)
+	if prev.Sign() < 0 {
+		return nil, fmt.Errorf("%s is the first /%d network", canonicalNet(n), ones)
+	}
+	return &net.IPNet{IP: intToIP(prev, size/8), Mask: n.Mask}, nil
+}
@@ -0,0 +1,68 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestSubnetIteratorCount(t *testing.T) {
+	_, network, _ := net.ParseCIDR("10.0.0.0/16")
+	it, err := NewSubnetIterator(network, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	count := 0
+	var last *net.IPNet
+	for subnet, ok := it.Next(); ok; subnet, ok = it.Next() {
+		count++
+		last = subnet
+	}
+	if count != 1<<14 {
+		t.Errorf("walked %d /30 subnets of a /16, want %d", count, 1<<14)
+	}
+	if last == nil || last.String() != "10.0.255.252/30" {
+		t.Errorf("last subnet = %v, want 10.0.255.252/30", last)
+	}
+}
+
+func TestSubnetCount(t *testing.T) {
+	if count, err := SubnetCount(16, 24); err != nil || count != 256 {
+		t.Errorf("SubnetCount(16, 24) = %d, %v, want 256", count, err)
+	}
+	if count, err := SubnetCount(0, 63); err != nil || count != 1<<63 {
+		t.Errorf("SubnetCount(0, 63) = %d, %v, want 2^63", count, err)
+	}
+	for _, prefixes := range [][2]int{{24, 24}, {24, 16}, {0, 64}} {
+		if _, err := SubnetCount(prefixes[0], prefixes[1]); err == nil {
+			t.Errorf("SubnetCount(%d, %d) succeeded, want an error", prefixes[0], prefixes[1])
+		}
+	}
+}
+
+func TestMappedNetworkAsIPv6(t *testing.T) {
+	info, err := CalculateFamily("::ffff:192.168.1.0/120", FamilyIPv6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	network := info.IPNet()
+
+	next, err := NextNetwork(network)
+	if err != nil || !next.IP.Equal(net.ParseIP("::ffff:192.168.2.0")) || len(next.IP) != net.IPv6len {
+		t.Errorf("NextNetwork = %v, %v, want ::ffff:192.168.2.0/120", next, err)
+	}
+	prev, err := PrevNetwork(network)
+	if err != nil || !prev.IP.Equal(net.ParseIP("::ffff:192.168.0.0")) || len(prev.IP) != net.IPv6len {
+		t.Errorf("PrevNetwork = %v, %v, want ::ffff:192.168.0.0/120", prev, err)
+	}
+
+	subnets, err := Split(network, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"::ffff:192.168.1.0", "::ffff:192.168.1.128"} {
+		if ones, _ := subnets[i].Mask.Size(); !subnets[i].IP.Equal(net.ParseIP(want)) || ones != 121 {
+			t.Errorf("Split()[%d] = %v, want %s/121", i, subnets[i], want)
+		}
+	}
+}
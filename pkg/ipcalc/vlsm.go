@@ -0,0 +1,85 @@
+package ipcalc
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+	"sort"
+)
+
+// Allocation is a subnet assigned to a host requirement by VLSM.
+type Allocation struct {
+	// Hosts is the number of hosts that were requested.
+	Hosts   int
+	Network *net.IPNet
+}
+
+// VLSM allocates a subnet of parent for each host requirement, using the
+// smallest prefix that fits each one. Allocations are made largest first,
+// which keeps every subnet aligned without leaving gaps, and are returned in
+// that order. Every IPv4 subnet has a network and broadcast address, so 1 or
+// 2 hosts get a /30, unless pointToPoint allows a /32 or a /31
+// point-to-point link (RFC 3021).
+func VLSM(parent *net.IPNet, hosts []int, pointToPoint bool) ([]Allocation, error) {
+	parent = canonicalNet(parent)
+	ones, size := parent.Mask.Size()
+
+	allocations := make([]Allocation, 0, len(hosts))
+	for _, h := range hosts {
+		prefix, err := prefixForHosts(h, size, pointToPoint)
+		if err != nil {
+			return nil, err
+		}
+		if prefix < ones {
+			return nil, fmt.Errorf("%d hosts do not fit in %s", h, parent)
+		}
+		allocations = append(allocations, Allocation{Hosts: h, Network: &net.IPNet{Mask: net.CIDRMask(prefix, size)}})
+	}
+	sort.SliceStable(allocations, func(i, j int) bool {
+		return maskSize(allocations[i].Network.Mask) < maskSize(allocations[j].Network.Mask)
+	})
+
+	next := addressInt(parent.IP, size)
+	end := new(big.Int).Add(next, TotalAddresses(parent.Mask))
+	for _, a := range allocations {
+		a.Network.IP = intToIP(next, size/8)
+		next = new(big.Int).Add(next, TotalAddresses(a.Network.Mask))
+		if next.Cmp(end) > 0 {
+			return nil, fmt.Errorf("the requested subnets do not fit in %s", parent)
+		}
+	}
+	return allocations, nil
+}
+
+// MaskForHosts returns the IPv4 netmask of the smallest network with at
+// least hosts usable addresses, such as 255.255.254.0 (/23) for 500 hosts.
+// As in VLSM, a /31 or /32 is only returned when pointToPoint is set.
+func MaskForHosts(hosts int, pointToPoint bool) (net.IPMask, error) {
+	prefix, err := prefixForHosts(hosts, 8*net.IPv4len, pointToPoint)
+	if err != nil {
+		return nil, err
+	}
+	return net.CIDRMask(prefix, 8*net.IPv4len), nil
+}
+
+// prefixForHosts returns the longest prefix of an address of size bits whose
+// network has at least hosts usable addresses. IPv4 networks with fewer than
+// two host bits, which have no network or broadcast address, are skipped
+// unless pointToPoint is set.
+func prefixForHosts(hosts, size int, pointToPoint bool) (int, error) {
+	if hosts < 1 {
+		return 0, fmt.Errorf("invalid host count %d", hosts)
+	}
+
+	longest := size
+	if size == 8*net.IPv4len && !pointToPoint {
+		longest = size - 2
+	}
+	want := big.NewInt(int64(hosts))
+	for prefix := longest; prefix >= 0; prefix-- {
+		if UsableHosts(net.CIDRMask(prefix, size)).Cmp(want) >= 0 {
+			return prefix, nil
+		}
+	}
+	return 0, fmt.Errorf("no /%d network has %d usable hosts", size, hosts)
+}
@@ -0,0 +1,42 @@
+package ipcalc
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMaskForHostsPointToPoint(t *testing.T) {
+	tests := []struct {
+		hosts        int
+		pointToPoint bool
+		want         int
+	}{
+		{1, false, 30},
+		{2, false, 30},
+		{3, false, 29},
+		{1, true, 32},
+		{2, true, 31},
+		{500, false, 23},
+	}
+
+	for _, tt := range tests {
+		mask, err := MaskForHosts(tt.hosts, tt.pointToPoint)
+		if err != nil {
+			t.Fatalf("MaskForHosts(%d, %v) failed: %v", tt.hosts, tt.pointToPoint, err)
+		}
+		if got := maskSize(mask); got != tt.want {
+			t.Errorf("MaskForHosts(%d, %v) = /%d, want /%d", tt.hosts, tt.pointToPoint, got, tt.want)
+		}
+	}
+}
+
+func TestVLSMTwoHosts(t *testing.T) {
+	_, parent, _ := net.ParseCIDR("10.0.0.0/24")
+	allocations, err := VLSM(parent, []int{50, 2}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := allocations[1].Network.String(); got != "10.0.0.64/30" {
+		t.Errorf("2 hosts got %s, want 10.0.0.64/30", got)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// replHelp is printed by the help command of the interactive mode.
+const replHelp = `Enter a network to calculate it, or an expression to evaluate it.
+An expression may leave out its network to use the last one, as in
+"+ 1" for the next network, "-" for the previous one or "split 2".
+"help" prints this message and "quit" or end of input exits.`
+
+// runInteractive reads networks and expressions from stdin one line at a
+// time, printing each result before reading the next, until end of input or
+// "quit". The last network calculated or returned by an expression is kept
+// so that later expressions can start with their operator.
+func runInteractive() {
+	prompt := !stdinIsPipe()
+	var last *net.IPNet
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if prompt {
+			fmt.Print("ipcalc> ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case isComment(line):
+			continue
+		case line == "quit" || line == "exit":
+			return
+		case line == "help":
+			fmt.Println(replHelp)
+			fmt.Println()
+			fmt.Println(exprUsage)
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if _, ok := exprVerbs[fields[0]]; ok {
+			if last == nil {
+				fmt.Fprintln(os.Stderr, "no network to apply the expression to yet")
+				continue
+			}
+			if len(fields) == 1 && (fields[0] == "+" || fields[0] == "-") {
+				line += " 1"
+			}
+			line = last.String() + " " + line
+		}
+
+		if isExpr(line) {
+			result, err := evalExpr(line)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				continue
+			}
+			fmt.Println(result)
+			if network, err := ipcalc.ParseNetwork(result); err == nil && !strings.Contains(result, " ") {
+				last = network
+			}
+			continue
+		}
+
+		info, err := calculate(line)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		printInfo(info)
+		last = info.IPNet()
+	}
+
+	if err := scanner.Err(); err != nil {
+		fail(err)
+	}
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"os"
+	"strconv"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// maxSubnets is the number of subnets -split and -subnet list unless -force
+// is given.
+const maxSubnets = 65536
+
+// subnetsFlushEvery is how many subnets printSubnets buffers before writing
+// them out.
+const subnetsFlushEvery = 1024
+
+// subnetWriter writes the subnets listed by printSubnets in one of the
+// output formats, one at a time.
+type subnetWriter interface {
+	row(i int, subnet *net.IPNet)
+	end()
+}
+
+// printSubnets prints every /newPrefix subnet of network in the output
+// format selected by the flags. The subnets are generated and written one
+// at a time, so the first ones appear right away even for a large network.
+func printSubnets(network *net.IPNet, newPrefix int) {
+	it, err := ipcalc.NewSubnetIterator(network, newPrefix)
+	if err != nil {
+		fail(err)
+		return
+	}
+	ones, _ := network.Mask.Size()
+	if count, _ := ipcalc.SubnetCountBig(ones, newPrefix); !*force && count.Cmp(big.NewInt(maxSubnets)) > 0 {
+		fail(fmt.Errorf("%s has %s /%d subnets, pass -force to list more than %d", cidrString(network), count, newPrefix, maxSubnets))
+		return
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+
+	var out subnetWriter
+	switch {
+	case *terraform:
+		out = &listWriter{w: w, open: "[", sep: ", ", close: "]\n", item: quotedCIDR}
+	case *jsonArray:
+		out = &listWriter{w: w, open: "[\n  ", sep: ",\n  ", close: "\n]\n", item: quotedCIDR}
+	case *jsonOutput:
+		out = &listWriter{w: w, open: "[\n  ", sep: ",\n  ", close: "\n]\n", item: jsonSubnet}
+	case *csvOutput:
+		out = newCSVSubnetWriter(w)
+	default:
+		out = newTableWriter(w, network, newPrefix)
+	}
+
+	for i := 0; ; i++ {
+		subnet, ok := it.Next()
+		if !ok {
+			break
+		}
+		out.row(i, subnet)
+		if (i+1)%subnetsFlushEvery == 0 {
+			w.Flush()
+		}
+	}
+	out.end()
+}
+
+// listWriter writes subnets as a list literal, such as a JSON array, given
+// the text before, between and after the items.
+type listWriter struct {
+	w                *bufio.Writer
+	open, sep, close string
+	item             func(subnet *net.IPNet) string
+	written          bool
+}
+
+func (l *listWriter) row(i int, subnet *net.IPNet) {
+	if l.written {
+		l.w.WriteString(l.sep)
+	} else {
+		l.w.WriteString(l.open)
+	}
+	l.w.WriteString(l.item(subnet))
+	l.written = true
+}
+
+func (l *listWriter) end() {
+	if !l.written {
+		l.w.WriteString(l.open)
+	}
+	l.w.WriteString(l.close)
+}
+
+// quotedCIDR returns a subnet as a quoted string, valid in both JSON and
+// HCL.
+func quotedCIDR(subnet *net.IPNet) string {
+	return strconv.Quote(cidrString(subnet))
+}
+
+// jsonSubnet returns the calculation for a subnet as an indented JSON object,
+// as an element of a top-level array.
+func jsonSubnet(subnet *net.IPNet) string {
+	b, _ := json.MarshalIndent(newJSONInfo(calculateNet(subnet)), "  ", "  ")
+	return string(b)
+}
+
+// csvSubnetWriter writes subnets as CSV rows under the header.
+type csvSubnetWriter struct {
+	csv *csv.Writer
+}
+
+func newCSVSubnetWriter(w io.Writer) *csvSubnetWriter {
+	c := csv.NewWriter(w)
+	c.Write(csvHeader)
+	return &csvSubnetWriter{c}
+}
+
+func (c *csvSubnetWriter) row(i int, subnet *net.IPNet) {
+	c.csv.Write(csvRecord(calculateNet(subnet)))
+	if (i+1)%subnetsFlushEvery == 0 {
+		c.csv.Flush()
+	}
+}
+
+func (c *csvSubnetWriter) end() {
+	c.csv.Flush()
+}
+
+// tableWriter writes subnets as an aligned table, in the columns of
+// tableHeader. Rows are not held back to measure them all, so the columns
+// are sized from the first and last subnets: the last one has the largest
+// value in every octet, and so the widest addresses.
+type tableWriter struct {
+	table *table
+}
+
+func newTableWriter(w io.Writer, network *net.IPNet, newPrefix int) *tableWriter {
+	info := calculateNet(network)
+	lastIP := info.HostMax
+	if info.Broadcast != nil {
+		lastIP = info.Broadcast
+	}
+	first, _ := ipcalc.Locate(network, info.Network, newPrefix)
+	last, _ := ipcalc.Locate(network, lastIP, newPrefix)
+	count, _ := ipcalc.SubnetCountBig(info.Prefix, newPrefix)
+
+	return &tableWriter{newTable(w, tableHeader,
+		tableCells(0, calculateNet(first)),
+		tableCells(0, calculateNet(last)),
+		[]string{count.String()},
+	)}
+}
+
+func (t *tableWriter) row(i int, subnet *net.IPNet) {
+	t.table.write(tableCells(i, calculateNet(subnet)))
+}
+
+func (t *tableWriter) end() {}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+var tableHeader = []string{"#", "Network", "Range", "Broadcast", "Hosts"}
+
+// renderAllocations writes a VLSM plan as a table, with the number of hosts
+// each subnet was allocated for in an extra column.
+func renderAllocations(allocations []ipcalc.Allocation, w io.Writer) {
+	rows := make([][]string, len(allocations))
+	for i, a := range allocations {
+		rows[i] = append(tableCells(i, calculateNet(a.Network)), strconv.Itoa(a.Hosts))
+	}
+
+	t := newTable(w, append(tableHeader, "Needed"), rows...)
+	for _, cells := range rows {
+		t.write(cells)
+	}
+}
+
+func tableCells(i int, info ipcalc.Info) []string {
+	broadcast := "-"
+	if info.Broadcast != nil {
+		broadcast = info.Broadcast.String()
+	}
+	usable, hosts := usableRange(info), info.Hosts.String()
+	if !info.HasHosts() {
+		usable, hosts = "-", "-"
+	}
+
+	return []string{
+		strconv.Itoa(i + 1),
+		networkString(info),
+		usable,
+		broadcast,
+		hosts,
+	}
+}
+
+// table writes rows as an aligned table. Its columns are sized when it is
+// created, so that rows can be written as they are produced.
+type table struct {
+	w      io.Writer
+	widths []int
+}
+
+// newTable writes the header row of a table to w, and returns the table with
+// each column as wide as its widest cell in header and widest.
+func newTable(w io.Writer, header []string, widest ...[]string) *table {
+	t := &table{w: w, widths: make([]int, len(header))}
+	for _, cells := range append([][]string{header}, widest...) {
+		for j, cell := range cells {
+			t.widths[j] = max(t.widths[j], len(cell))
+		}
+	}
+
+	t.write(header)
+	return t
+}
+
+// write writes a row with every cell but the last padded to its column, two
+// spaces apart.
+func (t *table) write(cells []string) {
+	var b strings.Builder
+	for j, cell := range cells {
+		b.WriteString(cell)
+		if j < len(cells)-1 {
+			b.WriteString(strings.Repeat(" ", t.widths[j]-len(cell)+2))
+		}
+	}
+	fmt.Fprintln(t.w, b.String())
+}
@@ -0,0 +1,614 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+// Widths of the value column of the table, wide enough for the longest
+// address of each family followed by its prefix.
+const (
+	ipv4Width = 20
+	ipv6Width = 46
+)
+
+// tableWidth returns the width of the value column for the family of info.
+func tableWidth(info ipcalc.Info) int {
+	if info.IsIPv6() {
+		return ipv6Width
+	}
+	return ipv4Width
+}
+
+// binaryGrouping selects how ipToBinaryString separates the bits of an
+// address.
+type binaryGrouping int
+
+const (
+	// groupOctets separates IPv4 octets with dots and IPv6 hextets with
+	// colons.
+	groupOctets binaryGrouping = iota
+	// groupNibbles separates every four bits with a space.
+	groupNibbles
+	// groupNone writes the bits without any separator.
+	groupNone
+)
+
+// parseGrouping parses the name of a binary grouping: octet, nibble or none.
+func parseGrouping(s string) (binaryGrouping, error) {
+	switch s {
+	case "octet":
+		return groupOctets, nil
+	case "nibble":
+		return groupNibbles, nil
+	case "none":
+		return groupNone, nil
+	}
+	return 0, fmt.Errorf("invalid binary grouping %q: must be octet, nibble or none", s)
+}
+
+// Convert IP address to binary string representation. A pipe marks the
+// boundary between the first prefix bits and the host bits, unless prefix
+// covers none or all of the address.
+func ipToBinaryString(ip net.IP, prefix int, grouping binaryGrouping) string {
+	switch {
+	case grouping == groupNibbles:
+		return markBoundary(joinBits(ip, 4, " "), prefix)
+	case grouping == groupNone:
+		return markBoundary(joinBits(ip, 8, ""), prefix)
+	case len(ip) == net.IPv4len:
+		return markBoundary(joinBits(ip, 8, "."), prefix)
+	default:
+		// IPv6 addresses are grouped in hextets.
+		return markBoundary(joinBits(ip, 16, ":"), prefix)
+	}
+}
+
+// joinBits writes the bits of ip in groups of size bits, with sep between
+// consecutive groups.
+func joinBits(ip net.IP, size int, sep string) string {
+	bits := 8 * len(ip)
+
+	var b strings.Builder
+	b.Grow(bits + len(sep)*(bits/size-1))
+	for i := 0; i < bits; i++ {
+		if i > 0 && i%size == 0 {
+			b.WriteString(sep)
+		}
+		b.WriteByte('0' + ip[i/8]>>(7-i%8)&1)
+	}
+	return b.String()
+}
+
+// ipToAnnotatedBinaryString is like ipToBinaryString, but follows each
+// group of bits with its value: the decimal octet for IPv4, as in
+// 11000000(192).10101000(168).00000001(1).00000001(1), and the hexadecimal
+// hextet for IPv6. The bits are always grouped by octet or hextet.
+func ipToAnnotatedBinaryString(ip net.IP, prefix int) string {
+	if len(ip) == net.IPv4len {
+		groups := strings.Split(ipToBinaryString(ip, prefix, groupOctets), ".")
+		for i := range groups {
+			groups[i] += fmt.Sprintf("(%d)", ip[i])
+		}
+		return strings.Join(groups, ".")
+	}
+
+	groups := strings.Split(ipToBinaryString(ip, prefix, groupOctets), ":")
+	for i := range groups {
+		groups[i] += fmt.Sprintf("(%x)", uint16(ip[2*i])<<8|uint16(ip[2*i+1]))
+	}
+	return strings.Join(groups, ":")
+}
+
+// binaryStringToIP parses the binary representation of an address, as
+// printed by ipToBinaryString. Separators are ignored, so the string must
+// hold exactly 32 bits for IPv4 or 128 bits for IPv6.
+func binaryStringToIP(s string) (net.IP, error) {
+	bits := strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ':', '|', ' ':
+			return -1
+		}
+		return r
+	}, s)
+
+	if len(bits) != 8*net.IPv4len && len(bits) != 8*net.IPv6len {
+		return nil, fmt.Errorf("invalid binary address %q: expected 32 or 128 bits", s)
+	}
+
+	ip := make(net.IP, len(bits)/8)
+	for i := range ip {
+		octet, err := strconv.ParseUint(bits[8*i:8*i+8], 2, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid binary address %q", s)
+		}
+		ip[i] = byte(octet)
+	}
+	return ip, nil
+}
+
+// markBoundary inserts a pipe into a binary string after the first prefix
+// bits, keeping any group separator that falls there before the pipe.
+func markBoundary(binaryString string, prefix int) string {
+	bits := 0
+	for i, c := range binaryString {
+		if c != '0' && c != '1' {
+			continue
+		}
+		if bits == prefix {
+			if prefix == 0 {
+				return binaryString
+			}
+			return binaryString[:i] + "|" + binaryString[i:]
+		}
+		bits++
+	}
+	return binaryString
+}
+
+// printInfo prints the calculation table for a network to stdout, with
+// notes about the input on stderr.
+func printInfo(info ipcalc.Info) {
+	if info.Inferred {
+		fmt.Fprintf(os.Stderr, "note: no prefix given, assuming the classful default /%d\n", info.Prefix)
+	}
+	// Giving the network address is the usual way to write a network, so
+	// it is only pointed out with -verbose.
+	switch r := addressRole(info.Address, info.IPNet()); {
+	case info.HasHosts() && (r == roleBroadcast || r == roleNetwork && *verbose):
+		fmt.Fprintf(os.Stderr, "note: %s is the %s of %s\n", ipString(info, info.Address), r, networkString(info))
+	case info.HostBitsSet():
+		fmt.Fprintf(os.Stderr, "note: host %s within %s\n", hostPart(info), networkString(info))
+	}
+
+	if !info.HasHosts() {
+		fmt.Fprintf(os.Stderr, "note: %s\n", noHostsNote(info))
+	}
+	// Subnet zero has been usable for decades, so it is a -verbose note too.
+	if kind, parent := classfulSubnet(info); kind != "" && *verbose {
+		fmt.Fprintf(os.Stderr, "note: %s is the %s of its classful network %s\n", info.IPNet(), kind, parent)
+	}
+	if info.Prefix > 0 && slices.Contains(ipcalc.OverlappingScopes(info.IPNet()), "Link-Local") {
+		fmt.Fprintf(os.Stderr, "warning: %s overlaps 169.254.0.0/16, link-local (APIPA) addresses that are not generally routable\n", info.IPNet())
+	}
+
+	render(info, os.Stdout)
+}
+
+// render writes the calculation for a network to w in the text format
+// selected by the flags.
+func render(info ipcalc.Info, w io.Writer) {
+	if outputTemplate != nil {
+		if err := outputTemplate.Execute(w, info); err != nil {
+			fail(err)
+			return
+		}
+		fmt.Fprintln(w)
+		return
+	}
+	if *quiet {
+		fmt.Fprintln(w, networkString(info))
+		return
+	}
+	if *oneline {
+		fmt.Fprintln(w, oneLineSummary(info))
+		return
+	}
+	if *countOnly && !info.HasHosts() {
+		fmt.Fprintln(w, "n/a")
+		return
+	}
+	if *countOnly {
+		fmt.Fprintln(w, info.Hosts)
+		return
+	}
+
+	if info.IsIPv6() {
+		renderIPv6(info, w)
+	} else {
+		renderIPv4(info, w)
+	}
+
+	if *intOutput {
+		renderIntegers(info, w)
+	}
+	if *hexOutput {
+		renderHex(info, w)
+	}
+	if *reverse {
+		renderReverseZones(info, w)
+	}
+	if *neighbors {
+		renderNeighbors(info, w)
+	}
+}
+
+// compactOutput reports whether the selected text format prints each network
+// on a single line, so that several networks need no blank line between
+// them.
+func compactOutput() bool {
+	return outputTemplate != nil || *quiet || *oneline || *countOnly
+}
+
+// oneLineSummary condenses the calculation to a single line of key=value
+// fields following the network, for log lines and grepping.
+func oneLineSummary(info ipcalc.Info) string {
+	fields := []string{networkString(info), "net=" + ipString(info, info.Network)}
+	if info.Broadcast != nil {
+		fields = append(fields, "bcast="+info.Broadcast.String())
+	}
+	if !info.HasHosts() {
+		return strings.Join(append(fields, "hosts=n/a"), " ")
+	}
+	fields = append(fields,
+		"hosts="+info.Hosts.String(),
+		fmt.Sprintf("range=%s-%s", ipString(info, info.HostMin), ipString(info, info.HostMax)))
+	return strings.Join(fields, " ")
+}
+
+// renderIntegers writes the integer values of the address, network and, for
+// IPv4, broadcast address.
+func renderIntegers(info ipcalc.Info, w io.Writer) {
+	width := tableWidth(info)
+
+	writeRow(w, "Integer:", ipInt(info, info.Address), "", width, "address")
+	writeRow(w, "", ipInt(info, info.Network), "", width, "network")
+	if info.Broadcast != nil {
+		writeRow(w, "", ipcalc.IPToInt(info.Broadcast), "", width, "broadcast")
+	}
+}
+
+// role is the part an address plays within a network.
+type role int
+
+const (
+	roleHost role = iota
+	roleNetwork
+	roleBroadcast
+	roleOutOfRange
+)
+
+func (r role) String() string {
+	switch r {
+	case roleNetwork:
+		return "network address"
+	case roleBroadcast:
+		return "broadcast address"
+	case roleOutOfRange:
+		return "outside the network"
+	}
+	return "host address"
+}
+
+// addressRole returns the role of ip within n. Every address of a /31 or /32
+// (or their IPv6 equivalents) is a host, since there is no network or
+// broadcast address to set aside.
+func addressRole(ip net.IP, n *net.IPNet) role {
+	if !n.Contains(ip) {
+		return roleOutOfRange
+	}
+	if ones, bits := n.Mask.Size(); bits-ones <= 1 {
+		return roleHost
+	}
+
+	info := calculateNet(n)
+	switch {
+	case ip.Equal(info.Network):
+		return roleNetwork
+	case info.Broadcast != nil && ip.Equal(info.Broadcast):
+		return roleBroadcast
+	}
+	return roleHost
+}
+
+// classfulSubnet returns "zero subnet" or "all-ones subnet" when the network
+// is the first or the last subnet of its classful network, together with
+// that network. Classful subnetting once kept both of them unused.
+func classfulSubnet(info ipcalc.Info) (string, *net.IPNet) {
+	width, ok := subnetBits(info)
+	if !ok {
+		return "", nil
+	}
+
+	classful := info.Prefix - width
+	subnet := ipcalc.IPToUint32(info.Network) >> (32 - info.Prefix) & (1<<width - 1)
+	parent, _ := ipcalc.Supernet(info.IPNet(), classful)
+	switch subnet {
+	case 0:
+		return "zero subnet", parent
+	case 1<<width - 1:
+		return "all-ones subnet", parent
+	}
+	return "", nil
+}
+
+// subnetBits returns how many bits the prefix of an IPv4 network borrows
+// from the host part of its classful default mask, as a /26 borrows 2 bits
+// of a class C /24. It returns false for networks no longer than their
+// classful mask, and for addresses that have none.
+func subnetBits(info ipcalc.Info) (int, bool) {
+	if info.IsIPv6() {
+		return 0, false
+	}
+	classful, err := ipcalc.ClassfulPrefix(info.Network)
+	if err != nil || info.Prefix <= classful {
+		return 0, false
+	}
+	return info.Prefix - classful, true
+}
+
+// subnets64 returns how many /64 subnets, the usual size of an IPv6 LAN, an
+// IPv6 network holds. It returns false for IPv4 networks and for prefixes of
+// /64 or longer, which hold at most one.
+func subnets64(info ipcalc.Info) (*big.Int, bool) {
+	if !info.IsIPv6() || info.Prefix >= 64 {
+		return nil, false
+	}
+	count, _ := ipcalc.SubnetCountBig(info.Prefix, 64)
+	return count, true
+}
+
+// hostPart returns the host bits of the address given by the user. For IPv4
+// only the octets that hold host bits are shown, as in ".37" for
+// 192.168.1.37/24.
+func hostPart(info ipcalc.Info) string {
+	if info.IsIPv6() {
+		host := make(net.IP, net.IPv6len)
+		for i, b := range info.Address.To16() {
+			host[i] = b &^ info.Mask[i]
+		}
+		return host.String()
+	}
+
+	octets := []string{}
+	for i, b := range info.Address.To4() {
+		if info.Mask[i] != 0xff {
+			octets = append(octets, strconv.Itoa(int(b&^info.Mask[i])))
+		}
+	}
+	return "." + strings.Join(octets, ".")
+}
+
+// writeRow writes a table row: the label, the value padded to width and
+// painted in color, and the remaining text. A row without remaining text is
+// not padded, so that it does not end in spaces.
+func writeRow(w io.Writer, label string, value any, color string, width int, rest string) {
+	if rest == "" {
+		fmt.Fprintf(w, "%-10s %s\n", label, paint(fmt.Sprint(value), color))
+		return
+	}
+	fmt.Fprintf(w, "%-10s %s %s\n", label, paint(fmt.Sprintf("%-*v", width, value), color), rest)
+}
+
+func renderIPv4(info ipcalc.Info, w io.Writer) {
+	netmaskFmt := fmt.Sprintf("%s = %d", net.IP(info.Mask), info.Prefix)
+	networkFmt := fmt.Sprintf("%s /%d", info.Network, info.Prefix)
+
+	writeRow(w, "Address:", info.Address, "", ipv4Width, binary(info.Network, info.Prefix))
+	writeRow(w, "Netmask:", netmaskFmt, colorMask, ipv4Width, binary(net.IP(info.Mask), info.Prefix)+" "+maskAddresses(info.Mask))
+	writeRow(w, "Wildcard:", info.Wildcard, "", ipv4Width, binary(info.Wildcard, info.Prefix))
+	fmt.Fprintln(w, "=>")
+	writeRow(w, "Network:", networkFmt, colorNetwork, ipv4Width, binary(info.Network, info.Prefix))
+	if !info.HasHosts() {
+		writeRow(w, "Broadcast:", info.Broadcast, colorBroadcast, ipv4Width, binary(info.Broadcast, info.Prefix))
+		writeRow(w, "Hosts/Net:", "n/a", "", ipv4Width, classLine(info))
+	} else {
+		writeRow(w, "HostMin:", info.HostMin, "", ipv4Width, binary(info.HostMin, info.Prefix))
+		writeRow(w, "HostMax:", info.HostMax, "", ipv4Width, binary(info.HostMax, info.Prefix))
+		writeRow(w, "Usable range:", usableRange(info), "", ipv4Width, "")
+		writeRow(w, "Broadcast:", info.Broadcast, colorBroadcast, ipv4Width, binary(info.Broadcast, info.Prefix))
+		writeRow(w, "Gateway:", gatewayFor(info), "", ipv4Width, fmt.Sprintf("(%s usable)", *gateway))
+		writeRow(w, "Hosts/Net:", hostsWithCount(info.Hosts), "", ipv4Width, classLine(info))
+	}
+	if bits, ok := subnetBits(info); ok {
+		writeRow(w, "Subnets:", 1<<bits, "", ipv4Width, fmt.Sprintf("(%d subnet bits)", bits))
+	}
+	if *total {
+		writeRow(w, "Addresses:", info.Total, "", ipv4Width, "")
+	}
+}
+
+func renderIPv6(info ipcalc.Info, w io.Writer) {
+	netmaskFmt := fmt.Sprintf("%s = %d", net.IP(info.Mask), info.Prefix)
+	networkFmt := fmt.Sprintf("%s /%d", ipString(info, info.Network), info.Prefix)
+
+	writeRow(w, "Address:", ipString(info, info.Address), "", ipv6Width, binary(info.Network, info.Prefix))
+	writeRow(w, "Expanded:", ipcalc.ExpandIPv6(info.Address), "", ipv6Width, "")
+	writeRow(w, "Netmask:", netmaskFmt, colorMask, ipv6Width, binary(net.IP(info.Mask), info.Prefix)+" "+maskAddresses(info.Mask))
+	fmt.Fprintln(w, "=>")
+	writeRow(w, "Network:", networkFmt, colorNetwork, ipv6Width, binary(info.Network, info.Prefix))
+	writeRow(w, "HostMin:", ipString(info, info.HostMin), "", ipv6Width, binary(info.HostMin, info.Prefix))
+	writeRow(w, "HostMax:", ipString(info, info.HostMax), "", ipv6Width, binary(info.HostMax, info.Prefix))
+	writeRow(w, "Usable range:", usableRange(info), "", ipv6Width, "")
+	writeRow(w, "Hosts/Net:", hostsWithCount(info.Hosts), "", ipv6Width, info.Scope)
+	if count, ok := subnets64(info); ok {
+		writeRow(w, "/64s:", hostsWithCount(count), "", ipv6Width, "")
+	}
+	if *total {
+		writeRow(w, "Addresses:", info.Total, "", ipv6Width, "")
+	}
+}
+
+// maskAddresses returns the number of addresses a mask covers, such as
+// "(256 addresses)" for a /24. Counts above 2^32 are written as a power of
+// two.
+func maskAddresses(mask net.IPMask) string {
+	total := ipcalc.TotalAddresses(mask)
+	switch {
+	case total.BitLen() > 33:
+		return fmt.Sprintf("(2^%d addresses)", total.BitLen()-1)
+	case total.IsInt64() && total.Int64() == 1:
+		return "(1 address)"
+	}
+	return fmt.Sprintf("(%s addresses)", total)
+}
+
+// usableRange returns the first and last usable addresses of a network as
+// a single "HostMin - HostMax" string.
+func usableRange(info ipcalc.Info) string {
+	return fmt.Sprintf("%s - %s", ipString(info, info.HostMin), ipString(info, info.HostMax))
+}
+
+// noHostsNote explains why a network gets no host range or count.
+func noHostsNote(info ipcalc.Info) string {
+	return fmt.Sprintf("%s is %s, host calculations do not apply", info.IPNet(), info.Class)
+}
+
+// ipString returns an address of info in the notation of its family.
+// net.IP.String writes IPv4-mapped addresses in dotted decimal, which would
+// hide that -family v6 calculated ::ffff:192.168.1.0/120 as an IPv6 network.
+// The address may be in its 4-byte form, as ipcalc.NextIP returns it.
+func ipString(info ipcalc.Info, ip net.IP) string {
+	if !info.IsIPv6() {
+		return ip.String()
+	}
+	addr, _ := netip.AddrFromSlice(ip.To16())
+	return addr.String()
+}
+
+// ipInt returns the integer value of an address of info. An IPv4-mapped
+// address calculated as IPv6 keeps its ::ffff: prefix, which ipcalc.IPToInt
+// would drop.
+func ipInt(info ipcalc.Info, ip net.IP) *big.Int {
+	if info.IsIPv6() {
+		return new(big.Int).SetBytes(ip.To16())
+	}
+	return ipcalc.IPToInt(ip)
+}
+
+// cidrString returns n in CIDR notation. A network with a 16-byte mask is
+// written as IPv6, since net.IPNet.String writes an IPv4-mapped one as the
+// IPv4 network it maps.
+func cidrString(n *net.IPNet) string {
+	ones, size := n.Mask.Size()
+	if size != 8*net.IPv6len {
+		return n.String()
+	}
+	addr, _ := netip.AddrFromSlice(n.IP.To16())
+	return netip.PrefixFrom(addr, ones).String()
+}
+
+// calculateNet calculates an already parsed network in the family selected
+// with -family, so that the subnets and neighbors of an IPv4-mapped network
+// calculated as IPv6 are too.
+func calculateNet(n *net.IPNet) ipcalc.Info {
+	return ipcalc.CalculateNetFamily(n, family)
+}
+
+// networkString returns the network of info in CIDR notation, with the
+// address written by ipString.
+func networkString(info ipcalc.Info) string {
+	return fmt.Sprintf("%s/%d", ipString(info, info.Network), info.Prefix)
+}
+
+// hostsWithCount returns a host count followed by its friendly form from
+// formatCount, when the count is large enough to need one.
+func hostsWithCount(n *big.Int) string {
+	if n.Cmp(big.NewInt(1000)) < 0 {
+		return n.String()
+	}
+	return fmt.Sprintf("%s (%s)", n, formatCount(n))
+}
+
+// siPrefixes are the SI prefixes formatCount abbreviates counts with.
+var siPrefixes = []string{"k", "M", "G", "T", "P", "E", "Z", "Y", "R", "Q"}
+
+// formatCount returns n in a form that is readable at a glance: with
+// thousands separators below a million, as in 65,534, and abbreviated with
+// an SI prefix above, as in ~16.8M.
+func formatCount(n *big.Int) string {
+	if n.Cmp(big.NewInt(1000000)) < 0 {
+		digits := n.String()
+		var b strings.Builder
+		for i, d := range digits {
+			if i > 0 && (len(digits)-i)%3 == 0 {
+				b.WriteByte(',')
+			}
+			b.WriteRune(d)
+		}
+		return b.String()
+	}
+
+	f := new(big.Float).SetInt(n)
+	thousand := big.NewFloat(1000)
+	for _, prefix := range siPrefixes {
+		if f.Quo(f, thousand); f.Cmp(thousand) < 0 {
+			return fmt.Sprintf("~%s%s", f.Text('f', 1), prefix)
+		}
+	}
+	return "~" + new(big.Float).SetInt(n).Text('g', 2)
+}
+
+// gatewayFor returns the host suggested as the gateway, the first or the last
+// usable host depending on -gateway.
+func gatewayFor(info ipcalc.Info) net.IP {
+	if *gateway == "last" {
+		return info.HostMax
+	}
+	return info.HostMin
+}
+
+// classLine describes the class of the network together with its special-use
+// scope or, for ordinary addresses, whether it is private or public.
+func classLine(info ipcalc.Info) string {
+	if info.Scope != "" {
+		return fmt.Sprintf("%s, %s", info.Class, info.Scope)
+	}
+	return fmt.Sprintf("%s, %s", info.Class, info.Privacy)
+}
+
+// renderHex writes the address, netmask, network and, for IPv4, broadcast
+// address in hexadecimal.
+func renderHex(info ipcalc.Info, w io.Writer) {
+	width := tableWidth(info)
+
+	writeRow(w, "Hex:", ipcalc.IPToHex(info.Address), "", width, "address")
+	writeRow(w, "", ipcalc.IPToHex(net.IP(info.Mask)), "", width, "netmask")
+	writeRow(w, "", ipcalc.IPToHex(info.Network), "", width, "network")
+	if info.Broadcast != nil {
+		writeRow(w, "", ipcalc.IPToHex(info.Broadcast), "", width, "broadcast")
+	}
+}
+
+func renderReverseZones(info ipcalc.Info, w io.Writer) {
+	for i, zone := range ipcalc.ReverseDNSZones(info.IPNet()) {
+		label := ""
+		if i == 0 {
+			label = "Reverse:"
+		}
+		fmt.Fprintf(w, "%-10s %s\n", label, zone)
+	}
+
+	alignment := "not on an octet boundary"
+	if ipcalc.IsOctetAligned(info.Prefix) {
+		alignment = "on an octet boundary"
+	}
+	fmt.Fprintf(w, "%-10s /%d is %s\n", "Aligned:", info.Prefix, alignment)
+}
+
+// renderNeighbors writes a one-line summary of the networks of the same size
+// immediately before and after the network.
+func renderNeighbors(info ipcalc.Info, w io.Writer) {
+	for _, n := range []struct {
+		label    string
+		adjacent func(*net.IPNet) (*net.IPNet, error)
+	}{
+		{"Previous:", ipcalc.PrevNetwork},
+		{"Next:", ipcalc.NextNetwork},
+	} {
+		summary := "none"
+		if network, err := n.adjacent(info.IPNet()); err == nil {
+			summary = oneLineSummary(calculateNet(network))
+		}
+		fmt.Fprintf(w, "%-10s %s\n", n.label, summary)
+	}
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"tomasweigenast.com/ipcalc/pkg/ipcalc"
+)
+
+func TestRenderDefaultRoute(t *testing.T) {
+	info, err := ipcalc.Calculate("0.0.0.0/0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	render(info, &buf)
+	out := buf.String()
+	for _, want := range []string{
+		"Network:   0.0.0.0 /0",
+		"Broadcast: 255.255.255.255",
+		"Usable range: 0.0.0.1 - 255.255.255.254",
+		"Hosts/Net: 4294967294",
+		"All classes, Default Route",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestRenderMulticastHasNoHosts(t *testing.T) {
+	info, err := ipcalc.Calculate("224.0.0.0/24")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	render(info, &buf)
+	for _, unwanted := range []string{"HostMin:", "HostMax:", "Usable range:", "Gateway:"} {
+		if strings.Contains(buf.String(), unwanted) {
+			t.Errorf("output contains %q:\n%s", unwanted, buf.String())
+		}
+	}
+
+	if got, want := oneLineSummary(info), "224.0.0.0/24 net=224.0.0.0 bcast=224.0.0.255 hosts=n/a"; got != want {
+		t.Errorf("oneLineSummary = %q, want %q", got, want)
+	}
+}
+
+func TestCIDRStringMapped(t *testing.T) {
+	info, err := ipcalc.CalculateFamily("::ffff:192.168.1.0/120", ipcalc.FamilyIPv6)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := cidrString(info.IPNet()), "::ffff:192.168.1.0/120"; got != want {
+		t.Errorf("cidrString = %q, want %q", got, want)
+	}
+	if got, want := ipInt(info, info.Network).String(), "281473913979136"; got != want {
+		t.Errorf("ipInt = %s, want %s", got, want)
+	}
+}